@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/compression/vlc/table"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use: "analyze",
+	Short: "Print entropy and code statistics of each table generator",
+	Run: analyze,
+}
+
+func analyze(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	top, err := cmd.Flags().GetInt("top")
+	if err != nil{
+		handleError(err)
+	}
+
+	hist, err := corpusHistogram(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	entropy := hist.Entropy()
+
+	fmt.Printf("symbols: %d total, %d distinct\n", hist.Total(), len(hist))
+	fmt.Printf("entropy: %.4f bits/symbol\n\n", entropy)
+
+	tables := make([]table.EncodingTable, len(tableGenerators))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "method\tavg bits\tredundancy\tmax depth\t")
+
+	for i, g := range tableGenerators{
+		tables[i] = g.generator.NewTableFromHistogram(hist)
+		avg := tables[i].AverageCodeLength(hist)
+
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%d\t\n", g.name, avg, avg-entropy, tables[i].MaxCodeLength())
+	}
+	if err := w.Flush(); err != nil{
+		handleError(err)
+	}
+
+	if top <= 0{
+		return
+	}
+
+	fmt.Printf("\ntop %d symbols:\n", top)
+
+	fmt.Fprint(w, "symbol\tcount\tshare\t")
+	for _, g := range tableGenerators{
+		fmt.Fprintf(w, "%s\t", g.name)
+	}
+	fmt.Fprintln(w)
+
+	total := hist.Total()
+	for _, c := range hist.Top(top){
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t", strconv.QuoteRune(c.Char), c.Count, float64(c.Count)*100/float64(total))
+
+		for _, tbl := range tables{
+			fmt.Fprintf(w, "%s\t", tbl[c.Char])
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil{
+		handleError(err)
+	}
+}
+
+
+func init(){
+	rootCmd.AddCommand(analyzeCmd)
+
+	analyzeCmd.Flags().IntP("top", "n", 10, "number of the most frequent symbols to print")
+}
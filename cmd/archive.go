@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"archiver/lib/archive"
+	"archiver/lib/compression"
+	"archiver/lib/extract"
+	"archiver/lib/filter"
+)
+
+var ErrSkippedEntries = errors.New("entries are not extracted, the archive is kept")
+
+// packDir packs regular files, directories and symlinks of root into multi-file archive,
+// every file is encoded separately, symlinks are stored with their targets as contents
+func packDir(root string, encoder compression.Encoder, f filter.Filter) ([]byte, error){
+	var buf bytes.Buffer
+	w := archive.NewWriter(&buf, encoder)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "."{
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil{
+			return err
+		}
+
+		switch{
+			case d.IsDir():
+				return w.WriteFile(name, nil, info.Mode(), info.ModTime())
+			case info.Mode()&fs.ModeSymlink != 0:
+				target, err := os.Readlink(path)
+				if err != nil{
+					return err
+				}
+				return w.WriteFile(name, []byte(filepath.ToSlash(target)), info.Mode(), info.ModTime())
+			case !info.Mode().IsRegular():
+				// devices, pipes and sockets are skipped
+				return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil{
+			return err
+		}
+		if f != nil{
+			f.Encode(data)
+		}
+
+		return w.WriteFile(name, data, info.Mode(), info.ModTime())
+	})
+	if err != nil{
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil{
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// verifyDir checks that every entry of root packed by packDir decodes back from the archive
+func verifyDir(root string, data []byte, encoder compression.Encoder, f filter.Filter) error{
+	decoder, ok := encoder.(compression.Decoder)
+	if !ok{
+		return fmt.Errorf("%w: method can't decode", ErrVerify)
+	}
+
+	fsys, err := archive.NewFS(bytes.NewReader(data), int64(len(data)), decoder)
+	if err != nil{
+		return fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "."{
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		info, err := d.Info()
+		if err != nil{
+			return err
+		}
+
+		var want, got []byte
+		switch{
+			case d.IsDir():
+				if _, err := fsys.Stat(name); err != nil{
+					return fmt.Errorf("%w: %w", ErrVerify, err)
+				}
+				return nil
+			case info.Mode()&fs.ModeSymlink != 0:
+				target, err := os.Readlink(path)
+				if err != nil{
+					return err
+				}
+				link, err := fsys.ReadLink(name)
+				if err != nil{
+					return fmt.Errorf("%w: %w", ErrVerify, err)
+				}
+				want, got = []byte(filepath.ToSlash(target)), []byte(link)
+			case !info.Mode().IsRegular():
+				return nil
+			default:
+				if want, err = os.ReadFile(path); err != nil{
+					return err
+				}
+				if got, err = fsys.ReadFile(name); err != nil{
+					return fmt.Errorf("%w: %w", ErrVerify, err)
+				}
+				if f != nil{
+					f.Decode(got)
+				}
+		}
+
+		if !bytes.Equal(got, want){
+			return fmt.Errorf("%w: %s decodes differently", ErrVerify, name)
+		}
+
+		return nil
+	})
+}
+
+// dirSampleSize limits data read from directory to select the method
+const dirSampleSize = 4 << 20
+
+// dirSample returns contents of the first regular files of root,
+// up to dirSampleSize bytes
+func dirSample(root string, f filter.Filter) (string, error){
+	var buf []byte
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+		if !d.Type().IsRegular(){
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil{
+			return err
+		}
+		if f != nil{
+			f.Encode(data)
+		}
+
+		buf = append(buf, data[:min(len(data), dirSampleSize-len(buf))]...)
+		if len(buf) >= dirSampleSize{
+			buf = trimIncompleteRune(buf)
+			return filepath.SkipAll
+		}
+
+		return nil
+	})
+
+	return string(buf), err
+}
+
+// trimIncompleteRune removes the character cut at the end of data,
+// so samples of texts stay valid UTF-8
+func trimIncompleteRune(data []byte) []byte{
+	for i := len(data)-1; i >= 0 && i >= len(data)-utf8.UTFMax; i--{
+		if utf8.RuneStart(data[i]){
+			if !utf8.FullRune(data[i:]){
+				return data[:i]
+			}
+			break
+		}
+	}
+
+	return data
+}
+
+// unpackArchive extracts multi-file archive into dir,
+// entries are never written outside of it
+func unpackArchive(data []byte, x extract.Extractor, decoder compression.Decoder) error{
+	fsys, err := archive.NewFS(bytes.NewReader(data), int64(len(data)), decoder)
+	if err != nil{
+		return err
+	}
+
+	return x.Extract(fsys)
+}
+
+// newExtractor returns extractor into dir configured by --strip-components
+// and the overwrite flags: existing files are errors unless a policy is given,
+// --force replaces them as --overwrite does
+func newExtractor(cmd *cobra.Command, dir string, f filter.Filter) extract.Extractor{
+	strip, err := cmd.Flags().GetInt("strip-components")
+	if err != nil{
+		handleError(err)
+	}
+
+	noOverwrite, _ := cmd.Flags().GetBool("no-overwrite")
+	keepNewer, _ := cmd.Flags().GetBool("keep-newer")
+
+	policy := extract.Fail
+	switch{
+		case noOverwrite:
+			policy = extract.NoOverwrite
+		case keepNewer:
+			policy = extract.KeepNewer
+		case force(cmd):
+			policy = extract.Overwrite
+	}
+
+	return extract.NewExtractor(dir).WithStripComponents(strip).WithPolicy(policy).WithFilter(f)
+}
+
+// addExtractFlags adds flags of multi-file archive extraction
+func addExtractFlags(cmd *cobra.Command){
+	cmd.Flags().Int("strip-components", 0, "remove the number of leading path elements of archive entries")
+	cmd.Flags().Bool("overwrite", false, "replace existing files, as --force does")
+	cmd.Flags().Bool("no-overwrite", false, "skip entries of existing files instead of failing")
+	cmd.Flags().Bool("keep-newer", false, "keep existing files newer than archive entries, replace older ones")
+	cmd.MarkFlagsMutuallyExclusive("overwrite", "no-overwrite", "keep-newer")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/bench"
+	"archiver/lib/compression"
+)
+
+var benchCmd = &cobra.Command{
+	Use: "bench [dir]",
+	Short: "Measure every method on a directory or a synthetic corpus",
+	Run: benchmark,
+}
+
+// defaultCorpusFileSize is the size of synthetic corpus files
+const defaultCorpusFileSize = 1 << 20
+
+func benchmark(cmd *cobra.Command, args []string){
+	var corpus []bench.File
+
+	if len(args) > 0 && args[0] != ""{
+		var err error
+		if corpus, err = bench.ReadCorpus(args[0]); err != nil{
+			handleError(err)
+		}
+	} else{
+		size, err := cmd.Flags().GetInt("size")
+		if err != nil{
+			handleError(err)
+		}
+		corpus = bench.SyntheticCorpus(size)
+	}
+
+	var results []bench.Result
+	for _, m := range compression.Methods(){
+		for _, f := range corpus{
+			results = append(results, bench.Run(m, f))
+		}
+	}
+
+	var err error
+	switch format := cmd.Flag("format").Value.String(); format{
+		case "table":
+			err = writeBenchTable(results)
+		case "csv":
+			err = writeBenchCSV(results)
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil{
+		handleError(err)
+	}
+}
+
+func writeBenchTable(results []bench.Result) error{
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "method\tfile\tsize\tpacked\tratio\tpack MB/s\tunpack MB/s\tpeak memory\t")
+
+	for _, r := range results{
+		if r.Err != nil{
+			fmt.Fprintf(w, "%s\t%s\t%d\t\t\t\t\t\t%v\n", r.Method, r.File, r.RawSize, r.Err)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.3f\t%.2f\t%.2f\t%s\t\n",
+			r.Method, r.File, r.RawSize, r.Size, r.Ratio(), r.CompressSpeed(), r.DecompressSpeed(), formatBytes(r.PeakMemory))
+	}
+
+	return w.Flush()
+}
+
+func writeBenchCSV(results []bench.Result) error{
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"method", "file", "size", "packed", "ratio", "pack_mbps", "unpack_mbps", "peak_memory", "error"})
+
+	for _, r := range results{
+		errText := ""
+		if r.Err != nil{
+			errText = r.Err.Error()
+		}
+
+		w.Write([]string{
+			r.Method,
+			r.File,
+			strconv.FormatInt(r.RawSize, 10),
+			strconv.FormatInt(r.Size, 10),
+			strconv.FormatFloat(r.Ratio(), 'f', 4, 64),
+			strconv.FormatFloat(r.CompressSpeed(), 'f', 2, 64),
+			strconv.FormatFloat(r.DecompressSpeed(), 'f', 2, 64),
+			strconv.FormatUint(r.PeakMemory, 10),
+			errText,
+		})
+	}
+	w.Flush()
+
+	return w.Error()
+}
+
+// formatBytes returns size in KiB or MiB
+func formatBytes(size uint64) string{
+	if size >= 1 << 20{
+		return fmt.Sprintf("%.1fMiB", float64(size) / (1 << 20))
+	}
+
+	return fmt.Sprintf("%.1fKiB", float64(size) / (1 << 10))
+}
+
+
+func init(){
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().String("format", "table", "output format: table, csv")
+	benchCmd.Flags().Int("size", defaultCorpusFileSize, "size of synthetic corpus files in bytes")
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc"
+	"archiver/lib/encrypt"
+	"archiver/lib/trailer"
+)
+
+var catCmd = &cobra.Command{
+	Use: "cat",
+	Short: "Print a byte range of a vlc packed file without unpacking it",
+	Run: cat,
+}
+
+var ErrSeekableMethod = errors.New("only shanon_fano, haffman and alphabetic files are seekable")
+var ErrNegativeOffset = errors.New("offset must not be negative")
+
+func cat(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	offset, err := cmd.Flags().GetInt64("offset")
+	if err != nil{
+		handleError(err)
+	}
+	length, err := cmd.Flags().GetInt64("length")
+	if err != nil{
+		handleError(err)
+	}
+	if offset < 0{
+		handleError(ErrNegativeOffset)
+	}
+
+	f, err := openPacked(args[0])
+	if err != nil{
+		handleError(err)
+	}
+	defer f.Close()
+
+	var r io.ReaderAt = f
+
+	size, _, err := trailer.Read(f, f.size)
+	if err != nil{
+		handleError(err)
+	}
+
+	head := make([]byte, min(int64(compression.MaxHeaderSize), size))
+	if _, err := f.ReadAt(head, 0); err != nil{
+		handleError(err)
+	}
+
+	if encrypt.IsEncrypted(head){
+		// encrypted files are decrypted in memory
+		data, err := io.ReadAll(io.NewSectionReader(f, 0, size))
+		if err != nil{
+			handleError(err)
+		}
+		if data, err = decryptPacked(cmd, data); err != nil{
+			handleError(err)
+		}
+
+		r, size = bytes.NewReader(data), int64(len(data))
+		head = data[:min(compression.MaxHeaderSize, len(data))]
+	}
+
+	method, _, rest, err := compression.ReadHeader(head)
+	if err != nil{
+		handleError(err)
+	}
+	headerSize := int64(len(head) - len(rest))
+
+	decoder, ok := method.New().(vlc.EncoderDecoder)
+	if !ok{
+		handleError(ErrSeekableMethod)
+	}
+
+	if tablePath := cmd.Flag("table").Value.String(); tablePath != ""{
+		stored, err := readStoredTable(tablePath)
+		if err != nil{
+			handleError(err)
+		}
+		decoder = vlc.NewStatic(stored)
+	}
+
+	decoder = withLimits(cmd, decoder).(vlc.EncoderDecoder)
+
+	packed := io.NewSectionReader(r, headerSize, size-headerSize)
+
+	sr, err := vlc.NewSectionReader(packed, packed.Size(), decoder)
+	if err != nil{
+		handleError(err)
+	}
+
+	// negative length means up to the end
+	if length < 0 || offset+length > sr.Size(){
+		length = max(sr.Size()-offset, 0)
+	}
+
+	if _, err := io.Copy(os.Stdout, io.NewSectionReader(sr, offset, length)); err != nil{
+		handleError(err)
+	}
+}
+
+
+func init(){
+	rootCmd.AddCommand(catCmd)
+
+	catCmd.Flags().String("table", "", "pretrained table the file was packed with")
+	catCmd.Flags().Int64("offset", 0, "offset in the unpacked data")
+	catCmd.Flags().Int64("length", -1, "number of bytes to print, -1 means up to the end")
+	addLimitFlags(catCmd)
+	catCmd.Flags().StringArray("identity", nil, "file with secret keys made by keygen command, can be repeated")
+	catCmd.Flags().String("password-file", "", "file with the password of encrypted file, "+passwordEnv+" is used otherwise, or it is asked")
+}
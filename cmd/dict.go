@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"archiver/lib/compression/lz"
+)
+
+var dictCmd = &cobra.Command{
+	Use: "dict",
+	Short: "Manage LZ dictionaries",
+}
+
+var dictTrainCmd = &cobra.Command{
+	Use: "train",
+	Short: "Train LZ dictionary on sample files",
+	Run: trainDict,
+}
+
+const (
+	defaultDictFile = "dict.bin"
+	defaultDictSize = 16 << 10
+)
+
+var ErrDictMethod = errors.New("dictionary can be used with lz method only")
+
+func trainDict(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	size, err := cmd.Flags().GetInt("size")
+	if err != nil{
+		handleError(err)
+	}
+
+	var samples [][]byte
+	for _, path := range args{
+		s, err := readSamples(path)
+		if err != nil{
+			handleError(err)
+		}
+		samples = append(samples, s...)
+	}
+
+	dict, err := lz.TrainDictionary(samples, size)
+	if err != nil{
+		handleError(err)
+	}
+
+	data, err := dict.MarshalBinary()
+	if err != nil{
+		handleError(err)
+	}
+
+	err = os.WriteFile(cmd.Flag("output").Value.String(), data, 0644)
+	if err != nil{
+		handleError(err)
+	}
+}
+
+// readSamples reads every regular file in the directory as a sample
+func readSamples(path string) ([][]byte, error){
+	var res [][]byte
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+		if !d.Type().IsRegular(){
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil{
+			return err
+		}
+		res = append(res, data)
+
+		return nil
+	})
+
+	return res, err
+}
+
+func readDictionary(path string) (lz.Dictionary, error){
+	var dict lz.Dictionary
+
+	data, err := os.ReadFile(path)
+	if err != nil{
+		return dict, err
+	}
+
+	err = dict.UnmarshalBinary(data)
+
+	return dict, err
+}
+
+
+func init(){
+	rootCmd.AddCommand(dictCmd)
+	dictCmd.AddCommand(dictTrainCmd)
+
+	dictTrainCmd.Flags().StringP("output", "o", defaultDictFile, "path to the trained dictionary")
+	dictTrainCmd.Flags().Int("size", defaultDictSize, "maximum dictionary size in bytes")
+}
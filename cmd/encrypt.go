@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"archiver/lib/encrypt"
+)
+
+// passwordEnv is the environment variable with the password
+const passwordEnv = "ARCHIVER_PASSWORD"
+
+var ErrNoPassword = errors.New("password is not specified and can't be asked without a terminal")
+var ErrPasswordMismatch = errors.New("passwords don't match")
+
+// encrypted reports whether flags ask to encrypt the packed file
+func encrypted(cmd *cobra.Command) bool{
+	password, _ := cmd.Flags().GetBool("encrypt")
+	recipients, _ := cmd.Flags().GetStringArray("recipient")
+
+	return password || len(recipients) > 0
+}
+
+// encryptPacked encrypts data with the password and public keys given by flags,
+// the returned identity decrypts the file with the kept file key
+func encryptPacked(cmd *cobra.Command, data []byte) ([]byte, encrypt.Identity, error){
+	c, err := encrypt.ParseCipher(cmd.Flag("cipher").Value.String())
+	if err != nil{
+		return nil, nil, err
+	}
+
+	var recipients []encrypt.Recipient
+
+	if password, _ := cmd.Flags().GetBool("encrypt"); password{
+		kdf, err := encrypt.ParseKDF(cmd.Flag("kdf").Value.String())
+		if err != nil{
+			return nil, nil, err
+		}
+
+		password, err := readPassword(cmd, true)
+		if err != nil{
+			return nil, nil, err
+		}
+		recipients = append(recipients, encrypt.PasswordRecipient{Password: password, KDF: kdf})
+	}
+
+	paths, _ := cmd.Flags().GetStringArray("recipient")
+	for _, path := range paths{
+		keys, err := readKeys(path, encrypt.ParseRecipients)
+		if err != nil{
+			return nil, nil, err
+		}
+		recipients = append(recipients, keys...)
+	}
+
+	kept := &keptKey{}
+	for i, r := range recipients{
+		recipients[i] = keepingRecipient{Recipient: r, kept: kept}
+	}
+
+	encrypted, err := encrypt.Encrypt(data, c, recipients...)
+
+	return encrypted, kept, err
+}
+
+// keptKey is the file key kept while it's wrapped for recipients,
+// the packed file is decrypted with it without secret keys of recipients
+type keptKey struct{
+	fileKey []byte
+}
+
+func (k *keptKey) Unwrap(s encrypt.Stanza) ([]byte, error){
+	if k.fileKey == nil{
+		return nil, encrypt.ErrNoIdentity
+	}
+
+	return k.fileKey, nil
+}
+
+// keepingRecipient wraps the file key for Recipient and keeps it
+type keepingRecipient struct{
+	encrypt.Recipient
+	kept *keptKey
+}
+
+func (r keepingRecipient) Wrap(fileKey []byte) (encrypt.Stanza, error){
+	r.kept.fileKey = bytes.Clone(fileKey)
+
+	return r.Recipient.Wrap(fileKey)
+}
+
+// decryptPacked decrypts data if it is encrypted,
+// with identities given by --identity or with the password
+func decryptPacked(cmd *cobra.Command, data []byte) ([]byte, error){
+	if !encrypt.IsEncrypted(data){
+		return data, nil
+	}
+
+	var identities []encrypt.Identity
+
+	paths, _ := cmd.Flags().GetStringArray("identity")
+	for _, path := range paths{
+		keys, err := readKeys(path, encrypt.ParseIdentities)
+		if err != nil{
+			return nil, err
+		}
+		identities = append(identities, keys...)
+	}
+
+	if len(identities) == 0{
+		password, err := readPassword(cmd, false)
+		if err != nil{
+			return nil, err
+		}
+		identities = append(identities, encrypt.PasswordIdentity{Password: password})
+	}
+
+	return encrypt.Decrypt(data, identities...)
+}
+
+// readKeys parses the key file with parse
+func readKeys[T any](path string, parse func(r io.Reader) ([]T, error)) ([]T, error){
+	f, err := os.Open(path)
+	if err != nil{
+		return nil, err
+	}
+	defer f.Close()
+
+	keys, err := parse(f)
+	if err != nil{
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return keys, nil
+}
+
+// readPassword reads the password from --password-file, passwordEnv or the terminal,
+// the password typed on the terminal is asked twice if confirm is set
+func readPassword(cmd *cobra.Command, confirm bool) ([]byte, error){
+	if path := cmd.Flag("password-file").Value.String(); path != ""{
+		data, err := os.ReadFile(path)
+		if err != nil{
+			return nil, err
+		}
+
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+
+	if password := os.Getenv(passwordEnv); password != ""{
+		return []byte(password), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd){
+		return nil, ErrNoPassword
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil{
+		return nil, err
+	}
+
+	if confirm{
+		fmt.Fprint(os.Stderr, "Repeat password: ")
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil{
+			return nil, err
+		}
+
+		if !bytes.Equal(password, repeated){
+			return nil, ErrPasswordMismatch
+		}
+	}
+
+	return password, nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/encrypt"
+)
+
+var keygenCmd = &cobra.Command{
+	Use: "keygen",
+	Short: "Generate X25519 key pair for --recipient and --identity or Ed25519 key pair for signing",
+	Run: keygen,
+}
+
+const defaultKeyFile = "key.txt"
+
+var ErrUnknownKeyType = errors.New("unknown key type")
+
+func keygen(cmd *cobra.Command, args []string){
+	var secret, public string
+
+	switch keyType := cmd.Flag("type").Value.String(); keyType{
+		case "x25519":
+			identity, err := encrypt.GenerateX25519Identity()
+			if err != nil{
+				handleError(err)
+			}
+			secret, public = identity.String(), identity.Recipient().String()
+		case "ed25519":
+			var err error
+			if secret, public, err = signingKey(); err != nil{
+				handleError(err)
+			}
+		default:
+			handleError(fmt.Errorf("%w: %s", ErrUnknownKeyType, keyType))
+	}
+
+	content := fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", time.Now().Format(time.RFC3339), public, secret)
+
+	// the secret key must not be readable by others
+	f, err := os.OpenFile(cmd.Flag("output").Value.String(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil{
+		handleError(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil{
+		handleError(err)
+	}
+
+	fmt.Println(public)
+}
+
+
+func init(){
+	rootCmd.AddCommand(keygenCmd)
+
+	keygenCmd.Flags().String("type", "x25519", "key type: x25519 for encryption, ed25519 for signing")
+	keygenCmd.Flags().StringP("output", "o", defaultKeyFile, "path to the secret key file, the public key is printed")
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/compression"
+	"archiver/lib/encrypt"
+	"archiver/lib/filter"
+	"archiver/lib/volume"
+)
+
+var ErrOutputExists = errors.New("output file exists, use --force to overwrite it")
+var ErrVerify = errors.New("output doesn't match the source, the source is kept")
+var ErrRemoveStream = errors.New("the source can't be removed when reading stdin or writing stdout")
+var ErrOutputIsSource = errors.New("output is the source, the source can't be removed")
+
+// outputPath returns --output, or name in --output-dir,
+// or name next to input by default
+func outputPath(cmd *cobra.Command, input, name string) string{
+	if output := cmd.Flag("output").Value.String(); output != ""{
+		return output
+	}
+
+	dir := cmd.Flag("output-dir").Value.String()
+	if dir == ""{
+		dir = filepath.Dir(filepath.Clean(input))
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// force reports whether existing output files are overwritten,
+// by --force or by --overwrite of commands extracting archives
+func force(cmd *cobra.Command) bool{
+	force, _ := cmd.Flags().GetBool("force")
+	overwrite, _ := cmd.Flags().GetBool("overwrite")
+
+	return force || overwrite
+}
+
+// removeSource reports whether the source is removed after the output is verified:
+// --rm is set, --keep keeps it as the default does
+func removeSource(cmd *cobra.Command) bool{
+	rm, _ := cmd.Flags().GetBool("rm")
+	keep, _ := cmd.Flags().GetBool("keep")
+
+	return rm && !keep
+}
+
+// addOutputFlags adds flags of the output path, overwriting and removing the source
+func addOutputFlags(cmd *cobra.Command){
+	cmd.Flags().StringP("output", "o", "", "output path, - is stdout")
+	cmd.Flags().String("output-dir", "", "directory of the output, the directory of the source by default")
+	cmd.Flags().BoolP("stdout", "c", false, "write the output to stdout, the output of stdin - is always written to it")
+	cmd.Flags().Bool("force", false, "overwrite existing output files")
+	cmd.Flags().Bool("keep", false, "keep the source, the default")
+	cmd.Flags().Bool("rm", false, "remove the source after the output is written and verified")
+	cmd.MarkFlagsMutuallyExclusive("output", "output-dir", "stdout")
+	cmd.MarkFlagsMutuallyExclusive("keep", "rm")
+}
+
+
+// atomicFile is written to a temporary file next to path and renamed to path on Commit,
+// so path is never left half-written
+type atomicFile struct{
+	*os.File
+	path string
+	done bool
+}
+
+// createAtomic creates the temporary file of path, existing path is an error unless force is set
+func createAtomic(path string, force bool) (*atomicFile, error){
+	if err := checkOutput(path, force); err != nil{
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil{
+		return nil, err
+	}
+
+	if err := f.Chmod(0644); err != nil{
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &atomicFile{File: f, path: path}, nil
+}
+
+// Commit syncs the written data and renames the temporary file to path
+func (f *atomicFile) Commit() error{
+	f.done = true
+
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil{
+		err = closeErr
+	}
+	if err == nil{
+		err = os.Rename(f.Name(), f.path)
+	}
+
+	if err != nil{
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// Abort removes the temporary file unless it's committed
+func (f *atomicFile) Abort(){
+	if f.done{
+		return
+	}
+	f.done = true
+
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// checkOutput returns ErrOutputExists if path exists and force isn't set
+func checkOutput(path string, force bool) error{
+	if force{
+		return nil
+	}
+
+	if _, err := os.Lstat(path); err == nil{
+		return fmt.Errorf("%w: %s", ErrOutputExists, path)
+	}
+
+	return nil
+}
+
+// writeOutput writes data to path atomically, the path - is stdout
+func writeOutput(path string, data []byte, force bool) error{
+	if path == stdPath{
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	f, err := createAtomic(path, force)
+	if err != nil{
+		return err
+	}
+	defer f.Abort()
+
+	if _, err := f.Write(data); err != nil{
+		return err
+	}
+
+	return f.Commit()
+}
+
+// verifyPacked checks that packed decodes back to data before the source is removed,
+// decoded data is unfiltered by f if it isn't nil
+func verifyPacked(encoder compression.Encoder, packed []byte, f filter.Filter, data []byte) error{
+	decoder, ok := encoder.(compression.Decoder)
+	if !ok{
+		return fmt.Errorf("%w: method can't decode", ErrVerify)
+	}
+
+	decoded, err := decoder.Decode(packed)
+	if err != nil{
+		return fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+
+	unpacked := []byte(decoded)
+	if f != nil{
+		f.Decode(unpacked)
+	}
+	if !bytes.Equal(unpacked, data){
+		return fmt.Errorf("%w: packed data decodes differently", ErrVerify)
+	}
+
+	return nil
+}
+
+// verifyWritten checks that path reads back as data
+func verifyWritten(path string, written, data []byte) error{
+	if !bytes.Equal(written, data){
+		return fmt.Errorf("%w: %s reads back differently", ErrVerify, path)
+	}
+
+	return nil
+}
+
+// verifyFile reads the packed file back from path and unpacks it as unpack does:
+// trailers are checked, the file is decrypted by id if it's encrypted,
+// the header must be of method and check compares the payload with the source
+// using the filter from the header
+func verifyFile(path string, id encrypt.Identity, method compression.Method, check func(payload []byte, f filter.Filter) error) error{
+	written, _, err := readPacked(path)
+	if err != nil{
+		return err
+	}
+
+	content, err := packedContent(written, func(data []byte) ([]byte, error){
+		if id == nil || !encrypt.IsEncrypted(data){
+			return data, nil
+		}
+
+		return encrypt.Decrypt(data, id)
+	})
+	if err != nil{
+		return fmt.Errorf("%w: %s: %w", ErrVerify, path, err)
+	}
+
+	m, filterID, payload, err := compression.ReadHeader(content)
+	if err != nil{
+		return fmt.Errorf("%w: %s: %w", ErrVerify, path, err)
+	}
+	if m.ID != method.ID{
+		return fmt.Errorf("%w: %s is packed with %s", ErrVerify, path, m.Name)
+	}
+
+	f, err := filterByID(filterID)
+	if err != nil{
+		return fmt.Errorf("%w: %s: %w", ErrVerify, path, err)
+	}
+
+	return check(payload, f)
+}
+
+// sourceFile is the source removed after the output is verified,
+// it's removed only if it's still the file it was
+type sourceFile struct{
+	path string
+	info os.FileInfo
+}
+
+func statSource(path string) (sourceFile, error){
+	info, err := os.Lstat(path)
+
+	return sourceFile{path: path, info: info}, err
+}
+
+// packedSources returns the packed file or all its volumes as sources
+func packedSources(path string) ([]sourceFile, error){
+	p, err := openPacked(path)
+	if err != nil{
+		return nil, err
+	}
+	p.Close()
+
+	var res []sourceFile
+	for _, f := range p.files{
+		s, err := statSource(f.Name())
+		if err != nil{
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
+
+// checkOutput returns ErrOutputIsSource if output is the source or is inside the source directory,
+// paths are compared with symlinks resolved and existing files by os.SameFile, so hard links are found too
+func (s sourceFile) checkOutput(output string) error{
+	src, out := resolvePath(s.path), resolvePath(output)
+	if out == src || strings.HasPrefix(out, src+string(filepath.Separator)){
+		return fmt.Errorf("%w: %s", ErrOutputIsSource, output)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil{
+		return nil
+	}
+	if source, err := os.Stat(s.path); err == nil && os.SameFile(info, source){
+		return fmt.Errorf("%w: %s", ErrOutputIsSource, output)
+	}
+
+	return nil
+}
+
+// checkReplaced returns ErrOutputIsSource if the source is replaced, it's the output then
+func (s sourceFile) checkReplaced() error{
+	info, err := os.Lstat(s.path)
+	if err != nil{
+		return err
+	}
+	if !os.SameFile(info, s.info){
+		return fmt.Errorf("%w: %s is replaced", ErrOutputIsSource, s.path)
+	}
+
+	return nil
+}
+
+// remove removes the source unless it's replaced
+func (s sourceFile) remove() error{
+	if err := s.checkReplaced(); err != nil{
+		return err
+	}
+
+	if s.info.IsDir(){
+		return os.RemoveAll(s.path)
+	}
+
+	return os.Remove(s.path)
+}
+
+// removeSources removes all sources, none of them is removed if any is replaced
+func removeSources(sources []sourceFile) error{
+	for _, s := range sources{
+		if err := s.checkReplaced(); err != nil{
+			return err
+		}
+	}
+
+	var errs []error
+	for _, s := range sources{
+		errs = append(errs, s.remove())
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolvePath returns the absolute path with symlinks resolved,
+// only the directory is resolved if the path doesn't exist
+func resolvePath(path string) string{
+	abs, err := filepath.Abs(path)
+	if err != nil{
+		return filepath.Clean(path)
+	}
+
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil{
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil{
+		return filepath.Join(dir, filepath.Base(abs))
+	}
+
+	return abs
+}
+
+// checkVolumes returns ErrOutputExists if any of volumes of path exists and force isn't set
+func checkVolumes(path string, count int, force bool) error{
+	for i := 1; i <= count; i++{
+		if err := checkOutput(volume.Name(path, i), force); err != nil{
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"testing"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSourceFile_CheckOutput(t* testing.T){
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(path, []byte("source"), 0644); err != nil{
+		t.Fatal(err)
+	}
+	if err := os.Link(path, filepath.Join(dir, "hard.txt")); err != nil{
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil{
+		t.Fatal(err)
+	}
+
+	tests := []struct{
+		name string
+		source string
+		output string
+		wantErr error
+	}{
+		{name: "other file", source: path, output: filepath.Join(dir, "in.vlc")},
+		{name: "same path", source: path, output: path, wantErr: ErrOutputIsSource},
+		{name: "unclean path", source: path, output: filepath.Join(dir, "..", filepath.Base(dir), "in.txt"), wantErr: ErrOutputIsSource},
+		{name: "symlinked directory", source: path, output: filepath.Join(link, "in.txt"), wantErr: ErrOutputIsSource},
+		{name: "hard link", source: path, output: filepath.Join(dir, "hard.txt"), wantErr: ErrOutputIsSource},
+		{name: "inside source directory", source: dir, output: filepath.Join(dir, "sub", "out.vlc"), wantErr: ErrOutputIsSource},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			s, err := statSource(tt.source)
+			if err != nil{
+				t.Fatal(err)
+			}
+
+			if err := s.checkOutput(tt.output); !errors.Is(err, tt.wantErr){
+				t.Errorf("checkOutput() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSourceFile_Remove(t* testing.T){
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(path, []byte("source"), 0644); err != nil{
+		t.Fatal(err)
+	}
+
+	s, err := statSource(path)
+	if err != nil{
+		t.Fatal(err)
+	}
+
+	// the output written over the source with --force is the only copy of the data
+	if err := writeOutput(path, []byte("packed"), true); err != nil{
+		t.Fatal(err)
+	}
+
+	if err := s.remove(); !errors.Is(err, ErrOutputIsSource){
+		t.Fatalf("remove() error = #%v#, want #%v#", err, ErrOutputIsSource)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != "packed"{
+		t.Errorf("in.txt = #%q %v#, want #%q#", got, err, "packed")
+	}
+
+	if s, err = statSource(path); err != nil{
+		t.Fatal(err)
+	}
+	if err := s.remove(); err != nil{
+		t.Fatalf("remove() error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist){
+		t.Errorf("remove() kept the source, Stat() error = %v", err)
+	}
+}
+
+func Test_removeSource(t* testing.T){
+	tests := []struct{
+		name string
+		flags []string
+		want bool
+	}{
+		{name: "default", want: false},
+		{name: "keep", flags: []string{"--keep"}, want: false},
+		{name: "rm", flags: []string{"--rm"}, want: true},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			cmd := &cobra.Command{}
+			addOutputFlags(cmd)
+			if err := cmd.ParseFlags(tt.flags); err != nil{
+				t.Fatal(err)
+			}
+
+			if got := removeSource(cmd); got != tt.want{
+				t.Errorf("removeSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
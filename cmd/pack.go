@@ -2,20 +2,25 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"io"
 	"path/filepath"
 	"archiver/lib/compression/vlc"
 	"archiver/lib/compression"
-	"archiver/lib/compression/vlc/table/shanon_fano"
-	"archiver/lib/compression/vlc/table/haffman"
+	"archiver/lib/compression/lz"
+	"archiver/lib/compression/tunstall"
+	"archiver/lib/encrypt"
+	"archiver/lib/filter"
+	"archiver/lib/filter/bcj"
 )
 
 var packCmd = &cobra.Command{
 	Use: "pack",
-	Short: "Pack file",
+	Short: "Pack file or directory",
 	Run: pack,
 }
 
@@ -31,24 +36,86 @@ var packCmd = &cobra.Command{
 const packedExtension = "vlc"
 
 var ErrEmptyPath = errors.New("path to file is not specified")
-func pack(cmd *cobra.Command, args []string){
-
-	var encoder compression.Encoder
+var ErrUnknownFilter = errors.New("unknown filter")
+var ErrFilterMethod = errors.New("filters need a method encoding arbitrary bytes")
+var ErrAutoOptions = errors.New("auto method can't be used with pretrained table or dictionary")
 
+func pack(cmd *cobra.Command, args []string){
 	if len(args) == 0 || args[0] == ""{
 		handleError(ErrEmptyPath)
 	}
 
-	method := cmd.Flag("method").Value.String()
-	
-	switch method{
-		case "shanon_fano":
-			encoder = vlc.New(shanon_fano.NewGenerator())
-		case "haffman":
-			encoder = vlc.New(haffman.NewGenerator())
+	f, filterID, err := newFilter(cmd.Flag("filter").Value.String())
+	if err != nil{
+		handleError(err)
 	}
 
 	filePath := args[0]
+	if err := checkStdin(cmd, filePath); err != nil{
+		handleError(err)
+	}
+
+	output := outputPath(cmd, filePath, packedFileName(filePath))
+	if toStdout(cmd, filePath){
+		output = stdPath
+	}
+
+	if removeSource(cmd) && (filePath == stdPath || output == stdPath){
+		handleError(ErrRemoveStream)
+	}
+
+	if filePath == stdPath{
+		if err := packStream(cmd, os.Stdin, output, f, filterID); err != nil{
+			handleError(err)
+		}
+		return
+	}
+
+	// the output written over the source or into it would be removed with it
+	var source sourceFile
+	if removeSource(cmd){
+		if source, err = statSource(filePath); err != nil{
+			handleError(err)
+		}
+		if err := source.checkOutput(output); err != nil{
+			handleError(err)
+		}
+	}
+
+	if info, err := os.Stat(filePath); err == nil && info.IsDir(){
+		method, err := packMethod(cmd, f != nil, func() (string, error){ return dirSample(filePath, f) })
+		if err != nil{
+			handleError(err)
+		}
+
+		encoder := newEncoder(cmd, method)
+
+		packed, err := packDir(filePath, encoder, f)
+		if err != nil{
+			handleError(err)
+		}
+
+		if removeSource(cmd){
+			if err := verifyDir(filePath, packed, encoder, f); err != nil{
+				handleError(err)
+			}
+		}
+
+		verify := func(payload []byte, f filter.Filter) error{
+			return verifyDir(filePath, payload, encoder, f)
+		}
+		if err := writePacked(cmd, output, method, filterID, packed, verify); err != nil{
+			handleError(err)
+		}
+
+		if removeSource(cmd){
+			if err := source.remove(); err != nil{
+				handleError(err)
+			}
+		}
+		return
+	}
+
 	r, err:= os.Open(filePath)
 	if err != nil{
 		handleError(err)
@@ -60,12 +127,246 @@ func pack(cmd *cobra.Command, args []string){
 		handleError(err)
 	}
 
-	packed := encoder.Encode(string(data))
+	if err := packData(cmd, output, data, f, filterID); err != nil{
+		handleError(err)
+	}
+
+	if removeSource(cmd){
+		if err := source.remove(); err != nil{
+			handleError(err)
+		}
+	}
+}
+
+// packData filters and packs data into path, filterID of f is written to the header,
+// packed data is checked to decode back if the source is removed
+func packData(cmd *cobra.Command, path string, data []byte, f filter.Filter, filterID byte) error{
+	// data is filtered in place, the written file is compared with the source
+	source := data
+	if f != nil{
+		if removeSource(cmd){
+			source = bytes.Clone(data)
+		}
+		f.Encode(data)
+	}
 
-	err = os.WriteFile(packedFileName(filePath), packed, 0644)
+	method, err := packMethod(cmd, f != nil, func() (string, error){ return string(data), nil })
 	if err != nil{
-		handleError(err)
+		return err
+	}
+
+	encoder := newEncoder(cmd, method)
+
+	packed, err := encoder.Encode(string(data))
+	if err != nil{
+		return err
+	}
+
+	if removeSource(cmd){
+		if err := verifyPacked(encoder, packed, nil, data); err != nil{
+			return err
+		}
+	}
+
+	return writePacked(cmd, path, method, filterID, packed, func(payload []byte, f filter.Filter) error{
+		return verifyPacked(encoder, payload, f, source)
+	})
+}
+
+// writePacked writes the method header and packed data to path,
+// the file is encrypted if --encrypt or --recipient is set, signed if --sign is set
+// and protected by the recovery record if --recovery is set,
+// it's split into volumes path.001, path.002, ... if --volume-size is set.
+// The file is written to stdout if path is -. If the source is removed, the file is read back
+// and unpacked as unpack does, verify compares the payload with the source.
+func writePacked(cmd *cobra.Command, path string, method compression.Method, filterID byte, packed []byte, verify func(payload []byte, f filter.Filter) error) error{
+	data := append(compression.AppendHeader(nil, method, filterID), packed...)
+
+	var id encrypt.Identity
+	if encrypted(cmd){
+		var err error
+		if data, id, err = encryptPacked(cmd, data); err != nil{
+			return err
+		}
+	}
+
+	if keyPath := cmd.Flag("sign").Value.String(); keyPath != ""{
+		var err error
+		if data, err = signPacked(keyPath, data); err != nil{
+			return err
+		}
+	}
+
+	if cmd.Flag("recovery").Value.String() != ""{
+		var err error
+		if data, err = protectPacked(cmd, data); err != nil{
+			return err
+		}
+	}
+
+	var volumeSize int64
+	if s := cmd.Flag("volume-size").Value.String(); s != ""{
+		var err error
+		if volumeSize, err = parseSize(s); err != nil{
+			return err
+		}
 	}
+
+	if err := writePackedFile(path, data, volumeSize, force(cmd)); err != nil{
+		return err
+	}
+
+	if removeSource(cmd){
+		return verifyFile(path, id, method, verify)
+	}
+
+	return nil
+}
+
+// packMethod returns the method given by --method, auto method is selected
+// by estimates of the data returned by sample. Filtered data is binary,
+// it's packed with methods encoding arbitrary bytes only.
+func packMethod(cmd *cobra.Command, filtered bool, sample func() (string, error)) (compression.Method, error){
+	input := compression.Text
+	if filtered{
+		input = compression.Bytes
+	}
+
+	name := cmd.Flag("method").Value.String()
+	if name != compression.AutoMethod{
+		method, err := compression.Lookup(name)
+		if err == nil && filtered && method.Input != compression.Bytes{
+			return method, fmt.Errorf("%w: %s", ErrFilterMethod, name)
+		}
+
+		return method, err
+	}
+
+	if cmd.Flag("table").Value.String() != "" || cmd.Flag("dict").Value.String() != ""{
+		return compression.Method{}, ErrAutoOptions
+	}
+
+	str, err := sample()
+	if err != nil{
+		return compression.Method{}, err
+	}
+
+	method, estimates, err := compression.SelectMethod(str, input)
+
+	if explain, _ := cmd.Flags().GetBool("explain"); explain{
+		for _, e := range estimates{
+			if e.Err != nil{
+				fmt.Fprintf(os.Stderr, "%-12s %v\n", e.Method.Name, e.Err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%-12s ~%d bytes (%.1f%%)\n", e.Method.Name, e.Size, ratio(e.Size, len(str)))
+		}
+		if err == nil{
+			fmt.Fprintf(os.Stderr, "selected %s\n", method.Name)
+		}
+	}
+
+	return method, err
+}
+
+// newEncoder returns the encoder of method configured by flags
+func newEncoder(cmd *cobra.Command, method compression.Method) compression.Encoder{
+	var encoder compression.Encoder = method.New()
+
+	if _, ok := encoder.(tunstall.EncoderDecoder); ok{
+		codewordSize, err := cmd.Flags().GetInt("codeword-size")
+		if err != nil{
+			handleError(err)
+		}
+
+		g, err := tunstall.NewGenerator(codewordSize)
+		if err != nil{
+			handleError(err)
+		}
+		encoder = tunstall.New(g)
+	}
+
+	if tablePath := cmd.Flag("table").Value.String(); tablePath != ""{
+		if _, ok := encoder.(vlc.EncoderDecoder); !ok{
+			handleError(ErrTableMethod)
+		}
+
+		stored, err := readStoredTable(tablePath)
+		if err != nil{
+			handleError(err)
+		}
+		encoder = vlc.NewStatic(stored)
+	}
+
+	if dictPath := cmd.Flag("dict").Value.String(); dictPath != ""{
+		if _, ok := encoder.(lz.EncoderDecoder); !ok{
+			handleError(ErrDictMethod)
+		}
+
+		dict, err := readDictionary(dictPath)
+		if err != nil{
+			handleError(err)
+		}
+		encoder = lz.NewWithDictionary(dict)
+	}
+
+	if ed, ok := encoder.(vlc.EncoderDecoder); ok{
+		threads, err := cmd.Flags().GetInt("threads")
+		if err != nil{
+			handleError(err)
+		}
+		encoder = ed.WithThreads(threads)
+	}
+
+	return encoder
+}
+
+// ratio returns size in percents of rawSize
+func ratio(size int64, rawSize int) float64{
+	if rawSize == 0{
+		return 0
+	}
+
+	return float64(size) * 100 / float64(rawSize)
+}
+
+// header IDs of filters, they must never change
+const (
+	x86FilterID byte = iota + 1
+	arm64FilterID
+)
+
+// newFilter returns filter and its header ID by the filter name, empty name means no filter
+func newFilter(name string) (filter.Filter, byte, error){
+	var id byte
+	switch name{
+		case "":
+			return nil, 0, nil
+		case "x86":
+			id = x86FilterID
+		case "arm64":
+			id = arm64FilterID
+		default:
+			return nil, 0, fmt.Errorf("%w: %s", ErrUnknownFilter, name)
+	}
+
+	f, err := filterByID(id)
+
+	return f, id, err
+}
+
+// filterByID returns filter by its header ID, 0 means no filter
+func filterByID(id byte) (filter.Filter, error){
+	switch id{
+		case 0:
+			return nil, nil
+		case x86FilterID:
+			return bcj.NewX86(), nil
+		case arm64FilterID:
+			return bcj.NewARM64(), nil
+	}
+
+	return nil, fmt.Errorf("%w: ID %d", ErrUnknownFilter, id)
 }
 
 func packedFileName(path string) string{
@@ -84,7 +385,23 @@ func init(){
 	rootCmd.AddCommand(packCmd)
 
 
-	packCmd.Flags().StringP("method", "m", "", "copmression method: vlc")
+	packCmd.Flags().StringP("method", "m", "", "compression method: "+compression.AutoMethod+", "+strings.Join(compression.Names(), ", "))
+	packCmd.Flags().Bool("explain", false, "print estimates of all methods for auto method")
+	packCmd.Flags().Int("codeword-size", tunstall.DefaultCodewordSize, "tunstall codeword size in bits")
+	packCmd.Flags().String("table", "", "pretrained table made by train command")
+	packCmd.Flags().String("dict", "", "LZ dictionary made by dict train command")
+	packCmd.Flags().IntP("threads", "t", 0, "number of blocks processed concurrently, 0 means the number of CPUs")
+	packCmd.Flags().StringP("filter", "f", "", "executable filter applied before compression with lz method: x86, arm64")
+	packCmd.Flags().Bool("encrypt", false, "encrypt the packed file with a password")
+	packCmd.Flags().StringArray("recipient", nil, "file with public keys made by keygen command the file is encrypted to, can be repeated")
+	packCmd.Flags().String("cipher", "aes-256-gcm", "encryption cipher: aes-256-gcm, chacha20-poly1305")
+	packCmd.Flags().String("kdf", "argon2id", "password key derivation function: argon2id, scrypt")
+	packCmd.Flags().String("sign", "", "Ed25519 secret key made by keygen --type ed25519 to sign the file with")
+	packCmd.Flags().String("recovery", "", "size of Reed-Solomon recovery record in percents of the packed file, e.g. 5%")
+	packCmd.Flags().String("volume-size", "", "split the packed file into volumes of this size, e.g. 100M, 100MB")
+	packCmd.Flags().String("password-file", "", "file with the password, "+passwordEnv+" is used otherwise, or it is asked")
+	addOutputFlags(packCmd)
+	packCmd.MarkFlagsMutuallyExclusive("encrypt", "recipient")
 	if err := packCmd.MarkFlagRequired("method"); err != nil{
 		handleError(err)
 	}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/recovery"
+	"archiver/lib/trailer"
+)
+
+var repairCmd = &cobra.Command{
+	Use: "repair",
+	Short: "Repair damaged packed file with its recovery record",
+	Run: repair,
+}
+
+var ErrDamaged = errors.New("file is damaged, it may be repaired with repair command")
+
+func repair(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	data, volumeSize, err := readPacked(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	// the record checks its own data, damaged trailers are read anyway
+	content, trailers, err := trailer.SplitDamaged(data)
+	if err != nil{
+		handleError(err)
+	}
+
+	// the footer isn't covered by the record, it's rewritten with the trailers
+	footer := 0
+	if _, _, err := trailer.Split(data); errors.Is(err, trailer.ErrDamagedFooter){
+		footer = 1
+	}
+
+	if check, _ := cmd.Flags().GetBool("check"); check{
+		damaged, err := recovery.Check(content, trailers)
+		if err != nil{
+			handleError(err)
+		}
+		damaged += footer
+
+		fmt.Printf("%s: %d damaged blocks\n", args[0], damaged)
+		if damaged > 0{
+			handleError(ErrDamaged)
+		}
+		return
+	}
+
+	repaired, rec, count, err := recovery.Repair(content, trailers)
+	if err != nil{
+		handleError(err)
+	}
+	count += footer
+
+	if count == 0{
+		fmt.Printf("%s: no damage found\n", args[0])
+		return
+	}
+
+	// trailers are rewritten with a new checksum, other trailers aren't repaired,
+	// a damaged signature doesn't verify
+	var rest []trailer.Trailer
+	for _, t := range trailers{
+		if t.Type != trailer.Recovery{
+			rest = append(rest, t)
+		}
+	}
+
+	data, err = trailer.Append(repaired, append(rest, rec)...)
+	if err != nil{
+		handleError(err)
+	}
+
+	path, _ := volumeBase(args[0])
+	if err := writePackedFile(path, data, volumeSize, true); err != nil{
+		handleError(err)
+	}
+
+	fmt.Printf("%s: %d blocks repaired\n", args[0], count)
+}
+
+// protectPacked appends the recovery record of --recovery percents of the content size
+func protectPacked(cmd *cobra.Command, data []byte) ([]byte, error){
+	percent, err := parsePercent(cmd.Flag("recovery").Value.String())
+	if err != nil{
+		return nil, err
+	}
+
+	content, _, err := trailer.Split(data)
+	if err != nil{
+		return nil, err
+	}
+
+	rec, err := recovery.Protect(content, percent)
+	if err != nil{
+		return nil, err
+	}
+
+	return trailer.Append(data, rec)
+}
+
+// checkDamage checks content against its recovery record, files without it aren't checked
+func checkDamage(content []byte, trailers []trailer.Trailer) error{
+	damaged, err := recovery.Check(content, trailers)
+	switch{
+		case errors.Is(err, recovery.ErrNoRecord):
+			return nil
+		case err != nil:
+			return err
+		case damaged > 0:
+			return fmt.Errorf("%w: %d damaged blocks", ErrDamaged, damaged)
+	}
+
+	return nil
+}
+
+// parsePercent parses percents like 5% or 5
+func parsePercent(s string) (int, error){
+	percent, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil{
+		return 0, fmt.Errorf("%w: %s", recovery.ErrInvalidPercent, s)
+	}
+
+	return percent, nil
+}
+
+func init(){
+	rootCmd.AddCommand(repairCmd)
+
+	repairCmd.Flags().Bool("check", false, "only report the number of damaged blocks, fail if there are any")
+}
@@ -4,7 +4,8 @@ import (
 	"github.com/spf13/cobra"
 	"os"
 	"fmt"
-	
+
+	_ "archiver/lib/compression/codecs"
 )
 
 var rootCmd = &cobra.Command{
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/sign"
+	"archiver/lib/trailer"
+)
+
+var signCmd = &cobra.Command{
+	Use: "sign",
+	Short: "Sign packed file with Ed25519 key",
+	Run: signFile,
+}
+
+var verifyCmd = &cobra.Command{
+	Use: "verify",
+	Short: "Verify Ed25519 signature of packed file",
+	Run: verify,
+}
+
+func signFile(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	data, volumeSize, err := readPacked(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	if data, err = signPacked(cmd.Flag("key").Value.String(), data); err != nil{
+		handleError(err)
+	}
+
+	path, _ := volumeBase(args[0])
+	if err := writePackedFile(path, data, volumeSize, true); err != nil{
+		handleError(err)
+	}
+}
+
+func verify(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	f, err := os.Open(cmd.Flag("pubkey").Value.String())
+	if err != nil{
+		handleError(err)
+	}
+	defer f.Close()
+
+	key, err := sign.ReadPublicKey(f)
+	if err != nil{
+		handleError(err)
+	}
+
+	data, _, err := readPacked(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	content, trailers, err := trailer.Split(data)
+	if err != nil{
+		handleError(err)
+	}
+
+	if err := sign.Verify(content, trailers, key); err != nil{
+		handleError(err)
+	}
+
+	fmt.Printf("%s: signed by %s\n", args[0], sign.FormatPublicKey(key))
+}
+
+// signPacked appends the signature made with the key of keyPath,
+// signatures cover the content without trailers
+func signPacked(keyPath string, data []byte) ([]byte, error){
+	f, err := os.Open(keyPath)
+	if err != nil{
+		return nil, err
+	}
+	defer f.Close()
+
+	key, err := sign.ReadPrivateKey(f)
+	if err != nil{
+		return nil, err
+	}
+
+	content, _, err := trailer.Split(data)
+	if err != nil{
+		return nil, err
+	}
+
+	return trailer.Append(data, sign.Sign(content, key))
+}
+
+// signingKey returns a new Ed25519 key pair as the secret key file content and the public key
+func signingKey() (string, string, error){
+	pub, priv, err := sign.GenerateKey()
+	if err != nil{
+		return "", "", err
+	}
+
+	return sign.FormatPrivateKey(priv), sign.FormatPublicKey(pub), nil
+}
+
+
+func init(){
+	rootCmd.AddCommand(signCmd)
+	rootCmd.AddCommand(verifyCmd)
+
+	signCmd.Flags().String("key", "", "Ed25519 secret key made by keygen --type ed25519")
+	verifyCmd.Flags().String("pubkey", "", "Ed25519 public key of the signer")
+
+	if err := signCmd.MarkFlagRequired("key"); err != nil{
+		handleError(err)
+	}
+	if err := verifyCmd.MarkFlagRequired("pubkey"); err != nil{
+		handleError(err)
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/archive"
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc"
+	"archiver/lib/encrypt"
+	"archiver/lib/filter"
+)
+
+// stdPath is the path of stdin and stdout
+const stdPath = "-"
+
+// archivePeekSize is enough to find the archive magic after the method header
+const archivePeekSize = 16
+
+var ErrStdoutArchive = errors.New("multi-file archive can't be unpacked to stdout")
+var ErrStdoutVolumes = errors.New("volumes can't be written to stdout")
+var ErrStdinOutputDir = errors.New("output of stdin has no name to write into --output-dir, use --output")
+
+// toStdout reports whether the output of path is written to stdout: if --output is -,
+// or --stdout is set, or path is stdin, which has no name to derive the output from
+func toStdout(cmd *cobra.Command, path string) bool{
+	if output := cmd.Flag("output").Value.String(); output != ""{
+		return output == stdPath
+	}
+
+	stdout, _ := cmd.Flags().GetBool("stdout")
+
+	return stdout || path == stdPath
+}
+
+// checkStdin returns ErrStdinOutputDir if path is stdin and --output-dir is set,
+// the output of stdin is written to --output or stdout only
+func checkStdin(cmd *cobra.Command, path string) error{
+	if path == stdPath && cmd.Flag("output-dir").Value.String() != ""{
+		return ErrStdinOutputDir
+	}
+
+	return nil
+}
+
+// wholeFile reports whether the packed file is processed as a whole:
+// it's encrypted, signed, protected by the recovery record or split into volumes
+func wholeFile(cmd *cobra.Command) bool{
+	return encrypted(cmd) ||
+		cmd.Flag("sign").Value.String() != "" ||
+		cmd.Flag("recovery").Value.String() != "" ||
+		cmd.Flag("volume-size").Value.String() != ""
+}
+
+// packStream packs data read from r into path, vlc methods encode blocks
+// while reading, other methods, filters and whole file options read all data first.
+// Auto method is selected by the beginning of data.
+func packStream(cmd *cobra.Command, r io.Reader, path string, f filter.Filter, filterID byte) error{
+	if f != nil || wholeFile(cmd){
+		data, err := io.ReadAll(r)
+		if err != nil{
+			return err
+		}
+
+		return packData(cmd, path, data, f, filterID)
+	}
+
+	br := bufio.NewReaderSize(r, dirSampleSize)
+
+	method, err := packMethod(cmd, false, func() (string, error){
+		sample, err := br.Peek(dirSampleSize)
+		if err != nil && err != io.EOF{
+			return "", err
+		}
+
+		return string(trimIncompleteRune(sample)), nil
+	})
+	if err != nil{
+		return err
+	}
+
+	encoder := newEncoder(cmd, method)
+
+	ed, ok := encoder.(vlc.EncoderDecoder)
+	if !ok{
+		data, err := io.ReadAll(br)
+		if err != nil{
+			return err
+		}
+
+		packed, err := encoder.Encode(string(data))
+		if err != nil{
+			return err
+		}
+
+		// the source of a stream is never removed, the file isn't verified
+		return writePacked(cmd, path, method, 0, packed, nil)
+	}
+
+	out := os.Stdout
+	var file *atomicFile
+	if path != stdPath{
+		if file, err = createAtomic(path, force(cmd)); err != nil{
+			return err
+		}
+		defer file.Abort()
+		out = file.File
+	}
+
+	if _, err := out.Write(compression.AppendHeader(nil, method, 0)); err != nil{
+		return err
+	}
+
+	w := vlc.NewWriter(out, ed)
+	if _, err := io.Copy(w, br); err != nil{
+		return err
+	}
+	if err := w.Close(); err != nil{
+		return err
+	}
+
+	if file != nil{
+		return file.Commit()
+	}
+
+	return nil
+}
+
+// unpackStream decodes vlc file read from in to path while reading
+// and reports whether it did, other files are left to be read as a whole.
+// Filtered files are left too, filters work on whole data.
+// Trailers of the streamed file aren't read, verify and repair --check check them.
+func unpackStream(cmd *cobra.Command, in *bufio.Reader, path string) (bool, error){
+	if cmd.Flag("filter").Value.String() != ""{
+		return false, nil
+	}
+
+	head, err := in.Peek(compression.MaxHeaderSize)
+	if err != nil && err != io.EOF{
+		return false, err
+	}
+	if encrypt.IsEncrypted(head){
+		return false, nil
+	}
+
+	method, filterID, rest, err := compression.ReadHeader(head)
+	if err != nil{
+		return false, err
+	}
+	if filterID != 0{
+		return false, nil
+	}
+
+	ed, ok := newDecoder(cmd, method).(vlc.EncoderDecoder)
+	if !ok{
+		return false, nil
+	}
+
+	headerSize := len(head) - len(rest)
+
+	// multi-file archives are extracted to a directory from the whole data
+	prefix, err := in.Peek(headerSize + archivePeekSize)
+	if err != nil && err != io.EOF{
+		return false, err
+	}
+	if archive.IsArchive(prefix[headerSize:]){
+		return false, nil
+	}
+
+	if _, err := in.Discard(headerSize); err != nil{
+		return false, err
+	}
+
+	out := os.Stdout
+	var file *atomicFile
+	if path != stdPath{
+		if file, err = createAtomic(path, force(cmd)); err != nil{
+			return true, err
+		}
+		defer file.Abort()
+		out = file.File
+	}
+
+	if _, err := io.Copy(out, vlc.NewReader(in, ed)); err != nil{
+		return true, err
+	}
+
+	if file != nil{
+		return true, file.Commit()
+	}
+
+	return true, nil
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+func Test_checkStdin(t* testing.T){
+	tests := []struct{
+		name string
+		path string
+		flags []string
+		wantErr error
+	}{
+		{name: "stdin", path: stdPath},
+		{name: "stdin to output", path: stdPath, flags: []string{"--output", "out.vlc"}},
+		{name: "stdin to output dir", path: stdPath, flags: []string{"--output-dir", "out"}, wantErr: ErrStdinOutputDir},
+		{name: "file to output dir", path: "in.txt", flags: []string{"--output-dir", "out"}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			cmd := &cobra.Command{}
+			addOutputFlags(cmd)
+			if err := cmd.ParseFlags(tt.flags); err != nil{
+				t.Fatal(err)
+			}
+
+			if err := checkStdin(cmd, tt.path); !errors.Is(err, tt.wantErr){
+				t.Errorf("checkStdin() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"io"
+	"path/filepath"
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc/table"
+	"archiver/lib/compression/vlc/table/shanon_fano"
+	"archiver/lib/compression/vlc/table/haffman"
+	"archiver/lib/compression/vlc/table/alphabetic"
+)
+
+var trainCmd = &cobra.Command{
+	Use: "train",
+	Short: "Train code table on a corpus",
+	Run: train,
+}
+
+const defaultTableFile = "table.bin"
+
+var ErrTableMethod = errors.New("pretrained table can be used with shanon_fano, haffman and alphabetic methods only")
+
+// tableGenerators are the generators of vlc methods
+var tableGenerators = []struct{
+	name string
+	generator table.Generator
+}{
+	{name: "shanon_fano", generator: shanon_fano.NewGenerator()},
+	{name: "haffman", generator: haffman.NewGenerator()},
+	{name: "alphabetic", generator: alphabetic.NewGenerator()},
+}
+
+func train(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	generator, err := newGenerator(cmd.Flag("method").Value.String())
+	if err != nil{
+		handleError(err)
+	}
+
+	corpus, err := corpusHistogram(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	stored, err := table.NewStored(table.Train(generator, corpus))
+	if err != nil{
+		handleError(err)
+	}
+
+	data, err := stored.MarshalBinary()
+	if err != nil{
+		handleError(err)
+	}
+
+	err = os.WriteFile(cmd.Flag("output").Value.String(), data, 0644)
+	if err != nil{
+		handleError(err)
+	}
+}
+
+// corpusHistogram counts characters of all regular files in the directory or of the file,
+// files are streamed, the corpus is never kept in memory
+func corpusHistogram(path string) (table.Histogram, error){
+	w := table.NewHistogramWriter(0)
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+		if !d.Type().IsRegular(){
+			return nil
+		}
+
+		r, err := os.Open(p)
+		if err != nil{
+			return err
+		}
+		defer r.Close()
+
+		_, err = io.Copy(w, r)
+
+		return err
+	})
+
+	return w.Histogram(), err
+}
+
+func newGenerator(name string) (table.Generator, error){
+	for _, g := range tableGenerators{
+		if g.name == name{
+			return g.generator, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", compression.ErrUnknownMethod, name)
+}
+
+func readStoredTable(path string) (table.Stored, error){
+	var stored table.Stored
+
+	data, err := os.ReadFile(path)
+	if err != nil{
+		return stored, err
+	}
+
+	err = stored.UnmarshalBinary(data)
+
+	return stored, err
+}
+
+
+func init(){
+	rootCmd.AddCommand(trainCmd)
+
+	trainCmd.Flags().StringP("method", "m", "", "table generation method: shanon_fano, haffman, alphabetic")
+	trainCmd.Flags().StringP("output", "o", defaultTableFile, "path to the trained table")
+
+	if err := trainCmd.MarkFlagRequired("method"); err != nil{
+		handleError(err)
+	}
+}
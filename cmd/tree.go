@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"archiver/lib/compression/vlc/table"
+)
+
+var treeCmd = &cobra.Command{
+	Use: "tree",
+	Short: "Print code tree of the file as Graphviz DOT or JSON",
+	Run: tree,
+}
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+func tree(cmd *cobra.Command, args []string){
+	if len(args) == 0 || args[0] == ""{
+		handleError(ErrEmptyPath)
+	}
+
+	generator, err := newGenerator(cmd.Flag("method").Value.String())
+	if err != nil{
+		handleError(err)
+	}
+
+	hist, err := corpusHistogram(args[0])
+	if err != nil{
+		handleError(err)
+	}
+
+	tbl := generator.NewTableFromHistogram(hist)
+	codeTree := table.NewCodeTree(tbl, hist)
+
+	switch format := cmd.Flag("format").Value.String(); format{
+		case "dot":
+			err = codeTree.WriteDOT(os.Stdout)
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+
+			err = enc.Encode(struct{
+				Table map[string]string `json:"table"`
+				Tree *table.CodeTree `json:"tree"`
+			}{tbl.Strings(), codeTree})
+		default:
+			err = fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil{
+		handleError(err)
+	}
+}
+
+
+func init(){
+	rootCmd.AddCommand(treeCmd)
+
+	treeCmd.Flags().StringP("method", "m", "haffman", "table generation method: shanon_fano, haffman, alphabetic")
+	treeCmd.Flags().String("format", "dot", "output format: dot, json")
+}
@@ -2,20 +2,27 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"bufio"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"io"
 	"path/filepath"
+	"archiver/lib/archive"
 	"archiver/lib/compression/vlc"
 	"archiver/lib/compression"
-	"archiver/lib/compression/vlc/table/shanon_fano"
-	"archiver/lib/compression/vlc/table/haffman"
+	"archiver/lib/compression/lz"
+	"archiver/lib/compression/intcode"
+	"archiver/lib/compression/tunstall"
+	"archiver/lib/filter"
+	"archiver/lib/trailer"
 )
 
 
 var unpackCmd = &cobra.Command{
 	Use: "unpack",
-	Short: "Unpack file",
+	Short: "Unpack file or multi-file archive",
 	Run: unpack,
 }
 
@@ -30,42 +37,181 @@ var unpackCmd = &cobra.Command{
 // TODO: Take extension from file
 const unpackedExtension = "txt"
 //var ErrEmptyPath = errors.New("path to file is not specified")
+var ErrFilterMismatch = errors.New("filter doesn't match the file")
 
 func unpack(cmd *cobra.Command, args []string){
-	var decoder compression.Decoder
-
 	if len(args) == 0 || args[0] == ""{
 		handleError(ErrEmptyPath)
 	}
 
-	method := cmd.Flag("method").Value.String()
+	var data []byte
+	var sources []sourceFile
+	filePath := args[0]
+	if err := checkStdin(cmd, filePath); err != nil{
+		handleError(err)
+	}
 
-	switch method{
-		case "shanon_fano":
-			decoder = vlc.New(shanon_fano.NewGenerator())
-		case "haffman":
-			decoder = vlc.New(haffman.NewGenerator())
-	} 
+	if removeSource(cmd) && (filePath == stdPath || toStdout(cmd, filePath)){
+		handleError(ErrRemoveStream)
+	}
 
+	if filePath == stdPath{
+		in := bufio.NewReader(os.Stdin)
 
-	filePath := args[0]
-	r, err:= os.Open(filePath)
+		output := cmd.Flag("output").Value.String()
+		if output == ""{
+			output = stdPath
+		}
+
+		streamed, err := unpackStream(cmd, in, output)
+		if err != nil{
+			handleError(err)
+		}
+		if streamed{
+			return
+		}
+
+		if data, err = io.ReadAll(in); err != nil{
+			handleError(err)
+		}
+	}else{
+		// volumes file.vlc.001, file.vlc.002, ... are unpacked as file.vlc
+		var err error
+		if data, _, err = readPacked(filePath); err != nil{
+			handleError(err)
+		}
+		if removeSource(cmd){
+			if sources, err = packedSources(filePath); err != nil{
+				handleError(err)
+			}
+		}
+		filePath, _ = volumeBase(filePath)
+	}
+
+	data, err := packedContent(data, func(data []byte) ([]byte, error){ return decryptPacked(cmd, data) })
+	if err != nil{
+		handleError(err)
+	}
+
+	method, filterID, data, err := compression.ReadHeader(data)
 	if err != nil{
 		handleError(err)
 	}
-	defer r.Close()
+	decoder := newDecoder(cmd, method)
 
-	data, err := io.ReadAll(r)
+	f, err := packedFilter(cmd, filterID)
 	if err != nil{
 		handleError(err)
 	}
 
-	unpacked := decoder.Decode(data)
+	if archive.IsArchive(data){
+		if toStdout(cmd, args[0]){
+			handleError(ErrStdoutArchive)
+		}
+
+		// myDir.vlc -> myDir
+		dir := outputPath(cmd, filePath, strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+		checkSources(sources, dir)
 
-	err = os.WriteFile(unpackedFileName(filePath), []byte(unpacked), 0644)
+		// the archive is removed only if every entry is extracted
+		var skipped []string
+		x := newExtractor(cmd, dir, f).WithOnSkip(func(name string){ skipped = append(skipped, name) })
+		if err := unpackArchive(data, x, decoder); err != nil{
+			handleError(err)
+		}
+
+		if removeSource(cmd){
+			if len(skipped) > 0{
+				handleError(fmt.Errorf("%w: %s", ErrSkippedEntries, strings.Join(skipped, ", ")))
+			}
+			if err := removeSources(sources); err != nil{
+				handleError(err)
+			}
+		}
+		return
+	}
+
+	decoded, err := decoder.Decode(data)
 	if err != nil{
 		handleError(err)
 	}
+	unpacked := []byte(decoded)
+
+	if f != nil{
+		f.Decode(unpacked)
+	}
+
+	output := outputPath(cmd, filePath, unpackedFileName(filePath))
+	if toStdout(cmd, args[0]){
+		output = stdPath
+	}
+	checkSources(sources, output)
+
+	if err := writeOutput(output, unpacked, force(cmd)); err != nil{
+		handleError(err)
+	}
+
+	if removeSource(cmd){
+		written, err := os.ReadFile(output)
+		if err != nil{
+			handleError(err)
+		}
+		if err := verifyWritten(output, written, unpacked); err != nil{
+			handleError(err)
+		}
+
+		if err := removeSources(sources); err != nil{
+			handleError(err)
+		}
+	}
+}
+
+// checkSources stops if the output is any of the packed files removed after unpacking
+func checkSources(sources []sourceFile, output string){
+	for _, s := range sources{
+		if err := s.checkOutput(output); err != nil{
+			handleError(err)
+		}
+	}
+}
+
+// newDecoder returns the decoder of method configured by flags
+func newDecoder(cmd *cobra.Command, method compression.Method) compression.Decoder{
+	var decoder compression.Decoder = method.New()
+
+	if tablePath := cmd.Flag("table").Value.String(); tablePath != ""{
+		if _, ok := decoder.(vlc.EncoderDecoder); !ok{
+			handleError(ErrTableMethod)
+		}
+
+		stored, err := readStoredTable(tablePath)
+		if err != nil{
+			handleError(err)
+		}
+		decoder = vlc.NewStatic(stored)
+	}
+
+	if dictPath := cmd.Flag("dict").Value.String(); dictPath != ""{
+		if _, ok := decoder.(lz.EncoderDecoder); !ok{
+			handleError(ErrDictMethod)
+		}
+
+		dict, err := readDictionary(dictPath)
+		if err != nil{
+			handleError(err)
+		}
+		decoder = lz.NewWithDictionary(dict)
+	}
+
+	if ed, ok := decoder.(vlc.EncoderDecoder); ok{
+		threads, err := cmd.Flags().GetInt("threads")
+		if err != nil{
+			handleError(err)
+		}
+		decoder = ed.WithThreads(threads)
+	}
+
+	return withLimits(cmd, decoder)
 }
 
 
@@ -86,11 +232,88 @@ func unpackedFileName(path string) string{
 func init(){
 	rootCmd.AddCommand(unpackCmd)
 
-	unpackCmd.Flags().StringP("method", "m", "", "decompression method: vlc")
+	unpackCmd.Flags().String("table", "", "pretrained table the file was packed with")
+	unpackCmd.Flags().String("dict", "", "LZ dictionary the file was packed with")
+	unpackCmd.Flags().IntP("threads", "t", 0, "number of blocks processed concurrently, 0 means the number of CPUs")
+	unpackCmd.Flags().StringP("filter", "f", "", "executable filter of files packed before filters were written to the header: x86, arm64")
+	addLimitFlags(unpackCmd)
+	addExtractFlags(unpackCmd)
+	addOutputFlags(unpackCmd)
+	unpackCmd.MarkFlagsMutuallyExclusive("no-overwrite", "force")
+	unpackCmd.Flags().StringArray("identity", nil, "file with secret keys made by keygen command, can be repeated")
+	unpackCmd.Flags().String("password-file", "", "file with the password of encrypted file, "+passwordEnv+" is used otherwise, or it is asked")
 
-	if err := unpackCmd.MarkFlagRequired("method"); err != nil{
+}
+
+
+// withLimits applies --max-* flags to the decoder
+func withLimits(cmd *cobra.Command, decoder compression.Decoder) compression.Decoder{
+	var limits compression.Limits
+	var err error
+
+	flags := cmd.Flags()
+	if limits.MaxTableSymbols, err = flags.GetInt("max-symbols"); err != nil{
+		handleError(err)
+	}
+	if limits.MaxOutputSize, err = flags.GetInt64("max-output"); err != nil{
+		handleError(err)
+	}
+	if limits.MaxRatio, err = flags.GetFloat64("max-ratio"); err != nil{
 		handleError(err)
 	}
+	if limits.MaxMemory, err = flags.GetInt64("max-memory"); err != nil{
+		handleError(err)
+	}
+
+	switch d := decoder.(type){
+		case vlc.EncoderDecoder:
+			return d.WithLimits(limits)
+		case lz.EncoderDecoder:
+			return d.WithLimits(limits)
+		case tunstall.EncoderDecoder:
+			return d.WithLimits(limits)
+		case intcode.RiceCoder:
+			return d.WithLimits(limits)
+	}
+
+	return decoder
+}
+
+// addLimitFlags adds flags of decoding limits, 0 means unlimited
+func addLimitFlags(cmd *cobra.Command){
+	cmd.Flags().Int("max-symbols", 0, "the largest code table or dictionary stored in the file, 0 means unlimited")
+	cmd.Flags().Int64("max-output", 0, "the largest unpacked size in bytes, 0 means unlimited")
+	cmd.Flags().Float64("max-ratio", 0, "the largest unpacked to packed size ratio, 0 means unlimited")
+	cmd.Flags().Int64("max-memory", 0, "the largest memory in bytes used for decoding, 0 means unlimited")
+}
 
+// packedContent checks the recovery record of the packed file, removes its trailers
+// and decrypts it with decrypt, the content starts with the method header
+func packedContent(data []byte, decrypt func(data []byte) ([]byte, error)) ([]byte, error){
+	data, trailers, err := trailer.Split(data)
+	if err != nil{
+		return nil, err
+	}
+
+	if err := checkDamage(data, trailers); err != nil{
+		return nil, err
+	}
+
+	return decrypt(data)
 }
 
+// packedFilter returns the filter of filterID from the header,
+// --filter is used for files packed before filters were written to headers
+func packedFilter(cmd *cobra.Command, filterID byte) (filter.Filter, error){
+	f, id, err := newFilter(cmd.Flag("filter").Value.String())
+	switch{
+		case err != nil:
+			return nil, err
+		case filterID == 0:
+			return f, nil
+		case f != nil && id != filterID:
+			return nil, fmt.Errorf("%w: file is filtered with filter ID %d", ErrFilterMismatch, filterID)
+	}
+
+	return filterByID(filterID)
+}
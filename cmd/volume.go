@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"archiver/lib/volume"
+)
+
+var ErrInvalidSize = errors.New("invalid size")
+
+// packedFile is a packed file or all its volumes read as one file
+type packedFile struct{
+	io.ReaderAt
+	size int64
+	// volumeSize is the size of the first volume, 0 if the file isn't split
+	volumeSize int64
+	files []*os.File
+}
+
+// volumeBase returns the path of the packed file split into volumes:
+// file.vlc.001, file.vlc.003 and file.vlc are file.vlc if they are its volumes
+func volumeBase(path string) (string, bool){
+	if base, ok := volumePath(path); ok{
+		return base, true
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist){
+		if _, err := os.Stat(volume.Name(path, 1)); err == nil{
+			return path, true
+		}
+	}
+
+	return path, false
+}
+
+// volumePath returns file.vlc if path is a volume file.vlc.NNN of any number
+func volumePath(path string) (string, bool){
+	i := strings.LastIndexByte(path, '.')
+	if i < 0{
+		return path, false
+	}
+	if n, err := strconv.Atoi(path[i+1:]); err != nil || n < 1 || volume.Name(path[:i], n) != path{
+		return path, false
+	}
+
+	f, err := os.Open(path)
+	if err != nil{
+		return path, false
+	}
+	defer f.Close()
+
+	head := make([]byte, volume.HeaderSize)
+	if _, err := io.ReadFull(f, head); err != nil || !volume.IsVolume(head){
+		return path, false
+	}
+
+	return path[:i], true
+}
+
+// openPacked opens the packed file, volumes are opened together and checked
+// to be all volumes of one file in order
+func openPacked(path string) (*packedFile, error){
+	base, split := volumeBase(path)
+	if !split{
+		f, err := os.Open(path)
+		if err != nil{
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil{
+			f.Close()
+			return nil, err
+		}
+
+		return &packedFile{ReaderAt: f, size: info.Size(), files: []*os.File{f}}, nil
+	}
+
+	p := &packedFile{}
+
+	var parts []io.ReaderAt
+	var sizes []int64
+	// the count is known from the header of the first volume
+	for count := 1; len(p.files) < count; {
+		name := volume.Name(base, len(p.files)+1)
+
+		f, err := os.Open(name)
+		if errors.Is(err, fs.ErrNotExist){
+			p.Close()
+			return nil, fmt.Errorf("%w: %s", volume.ErrMissingVolume, name)
+		}
+		if err != nil{
+			p.Close()
+			return nil, err
+		}
+		p.files = append(p.files, f)
+
+		info, err := f.Stat()
+		if err != nil{
+			p.Close()
+			return nil, err
+		}
+		parts, sizes = append(parts, f), append(sizes, info.Size())
+
+		if len(p.files) == 1{
+			head := make([]byte, min(int64(volume.HeaderSize), info.Size()))
+			if _, err := f.ReadAt(head, 0); err != nil{
+				p.Close()
+				return nil, err
+			}
+			if count, err = volume.Count(head); err != nil{
+				p.Close()
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			p.volumeSize = info.Size()
+		}
+	}
+
+	r, err := volume.NewReader(parts, sizes)
+	if err != nil{
+		p.Close()
+		return nil, fmt.Errorf("%s: %w", base, err)
+	}
+	p.ReaderAt, p.size = r, r.Size()
+
+	return p, nil
+}
+
+func (p *packedFile) Close() error{
+	var errs []error
+	for _, f := range p.files{
+		errs = append(errs, f.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// readPacked reads the packed file or joins its volumes,
+// the volume size is 0 if the file isn't split
+func readPacked(path string) ([]byte, int64, error){
+	p, err := openPacked(path)
+	if err != nil{
+		return nil, 0, err
+	}
+	defer p.Close()
+
+	data, err := io.ReadAll(io.NewSectionReader(p, 0, p.size))
+
+	return data, p.volumeSize, err
+}
+
+// writePackedFile writes data to path, or to volumes path.001, path.002, ...
+// of volumeSize bytes at most if volumeSize isn't 0, the path - is stdout.
+// Existing files are overwritten only if force is set.
+func writePackedFile(path string, data []byte, volumeSize int64, force bool) error{
+	switch{
+		case path == stdPath && volumeSize != 0:
+			return ErrStdoutVolumes
+		case volumeSize == 0:
+			return writeOutput(path, data, force)
+	}
+
+	volumes, err := volume.Split(data, volumeSize)
+	if err != nil{
+		return err
+	}
+
+	// no volume is written if any of them exists
+	if err := checkVolumes(path, len(volumes), force); err != nil{
+		return err
+	}
+
+	for i, v := range volumes{
+		if err := writeOutput(volume.Name(path, i+1), v, force); err != nil{
+			// a part of the file is useless, written volumes are removed
+			for j := range i{
+				os.Remove(volume.Name(path, j+1))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseSize parses sizes like 100M or 1.5G, as split does K, M, G, T and KiB, MiB, ...
+// are powers of 1024, KB, MB, ... are powers of 1000. Sizes below a byte are invalid,
+// 0 would mean no size.
+func parseSize(s string) (int64, error){
+	str := strings.ToUpper(s)
+
+	base := int64(1024)
+	if rest, ok := strings.CutSuffix(str, "IB"); ok{
+		str = rest
+	}else if rest, ok := strings.CutSuffix(str, "B"); ok{
+		str, base = rest, 1000
+	}
+
+	unit := int64(1)
+	if len(str) > 0{
+		if i := strings.IndexByte("KMGT", str[len(str)-1]); i >= 0{
+			for ; i >= 0; i--{
+				unit *= base
+			}
+			str = str[:len(str)-1]
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	size := n * float64(unit)
+	// NaN fails the range check too
+	if err != nil || !(size >= 1 && size < 1<<62){
+		return 0, fmt.Errorf("%w: %s", ErrInvalidSize, s)
+	}
+
+	return int64(size), nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+	"errors"
+)
+
+func Test_parseSize(t* testing.T){
+	tests := []struct{
+		s string
+		want int64
+		wantErr error
+	}{
+		{s: "100", want: 100},
+		{s: "1K", want: 1024},
+		{s: "1.5M", want: 3 << 19},
+		{s: "1KB", want: 1000},
+		{s: "2MiB", want: 2 << 20},
+		{s: "1.5", want: 1},
+		{s: "0", wantErr: ErrInvalidSize},
+		{s: "0.5", wantErr: ErrInvalidSize},
+		{s: "0.4B", wantErr: ErrInvalidSize},
+		{s: "-1K", wantErr: ErrInvalidSize},
+		{s: "NaN", wantErr: ErrInvalidSize},
+		{s: "Inf", wantErr: ErrInvalidSize},
+		{s: "10X", wantErr: ErrInvalidSize},
+	}
+	for _, tt := range tests{
+		t.Run(tt.s, func(t* testing.T){
+			got, err := parseSize(tt.s)
+			if !errors.Is(err, tt.wantErr) || got != tt.want{
+				t.Errorf("parseSize() = #%d %v#, want #%d %v#", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
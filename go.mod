@@ -2,9 +2,14 @@ module archiver
 
 go 1.22.2
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/crypto v0.31.0
+	golang.org/x/term v0.27.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )
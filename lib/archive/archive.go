@@ -0,0 +1,230 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"time"
+
+	"archiver/lib/compression"
+)
+
+// archiveMagic starts and ends multi-file archives
+const archiveMagic = "VARC"
+
+// trailerSize is directory offset, entries count and magic
+const trailerSize = 8 + 4 + 4
+
+var ErrInvalidArchive = errors.New("invalid archive")
+var ErrInvalidName = errors.New("invalid entry name")
+var ErrDuplicateName = errors.New("duplicate entry name")
+
+// entry describes a file or a directory of the archive,
+// files are encoded separately, so each of them is decoded on its own
+type entry struct{
+	name string
+	mode fs.FileMode
+	modTime time.Time
+	rawSize int64
+	offset int64
+	size int64
+}
+
+// Writer writes multi-file archive:
+// magic | encoded files | directory | directory offset | count | magic
+type Writer struct{
+	w io.Writer
+	encoder compression.Encoder
+	entries []entry
+	names map[string]bool
+	offset int64
+	err error
+}
+
+// NewWriter returns Writer encoding every file with encoder
+func NewWriter(w io.Writer, encoder compression.Encoder) *Writer{
+	aw := &Writer{w: w, encoder: encoder, names: make(map[string]bool)}
+	aw.write([]byte(archiveMagic))
+
+	return aw
+}
+
+// WriteFile encodes data as file name, name is slash-separated path
+// satisfying fs.ValidPath. Directories are added with fs.ModeDir and no data,
+// parent directories don't have to be added.
+func (aw *Writer) WriteFile(name string, data []byte, mode fs.FileMode, modTime time.Time) error{
+	if aw.err != nil{
+		return aw.err
+	}
+
+	if !fs.ValidPath(name) || name == "."{
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	if aw.names[name]{
+		return fmt.Errorf("%w: %q", ErrDuplicateName, name)
+	}
+	aw.names[name] = true
+
+	e := entry{name: name, mode: mode, modTime: modTime, offset: aw.offset}
+
+	if !mode.IsDir(){
+		encoded, err := aw.encoder.Encode(string(data))
+		if err != nil{
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		e.rawSize, e.size = int64(len(data)), int64(len(encoded))
+		aw.write(encoded)
+	}
+
+	aw.entries = append(aw.entries, e)
+
+	return aw.err
+}
+
+// Close writes the directory, it doesn't close the underlying writer
+func (aw *Writer) Close() error{
+	if aw.err != nil{
+		return aw.err
+	}
+
+	dirOffset := aw.offset
+	aw.write(encodeDirectory(aw.entries))
+
+	trailer := make([]byte, 0, trailerSize)
+	trailer = binary.BigEndian.AppendUint64(trailer, uint64(dirOffset))
+	trailer = binary.BigEndian.AppendUint32(trailer, uint32(len(aw.entries)))
+	trailer = append(trailer, archiveMagic...)
+	aw.write(trailer)
+
+	return aw.err
+}
+
+func (aw *Writer) write(p []byte){
+	if aw.err != nil{
+		return
+	}
+
+	n, err := aw.w.Write(p)
+	aw.offset += int64(n)
+	aw.err = err
+}
+
+
+func encodeDirectory(entries []entry) []byte{
+	var buf []byte
+
+	for _, e := range entries{
+		buf = binary.AppendUvarint(buf, uint64(len(e.name)))
+		buf = append(buf, e.name...)
+		buf = binary.AppendUvarint(buf, uint64(e.mode))
+		buf = binary.AppendVarint(buf, e.modTime.UnixNano())
+		buf = binary.AppendUvarint(buf, uint64(e.rawSize))
+		buf = binary.AppendUvarint(buf, uint64(e.offset))
+		buf = binary.AppendUvarint(buf, uint64(e.size))
+	}
+
+	return buf
+}
+
+// readDirectory reads the directory of archive of the given size
+func readDirectory(r io.ReaderAt, size int64) ([]entry, error){
+	if size < int64(len(archiveMagic) + trailerSize){
+		return nil, fmt.Errorf("%w: archive is too small", ErrInvalidArchive)
+	}
+
+	trailer := make([]byte, trailerSize)
+	if _, err := r.ReadAt(trailer, size-trailerSize); err != nil{
+		return nil, err
+	}
+
+	if string(trailer[12:]) != archiveMagic{
+		return nil, fmt.Errorf("%w: archive trailer not found", ErrInvalidArchive)
+	}
+
+	dirOffset := binary.BigEndian.Uint64(trailer)
+	count := binary.BigEndian.Uint32(trailer[8:])
+
+	dirEnd := uint64(size - trailerSize)
+	if dirOffset < uint64(len(archiveMagic)) || dirOffset > dirEnd{
+		return nil, fmt.Errorf("%w: directory offset %d", ErrInvalidArchive, dirOffset)
+	}
+
+	dir := make([]byte, dirEnd-dirOffset)
+	if _, err := r.ReadAt(dir, int64(dirOffset)); err != nil{
+		return nil, err
+	}
+
+	br := bytes.NewReader(dir)
+	var entries []entry
+
+	for i := uint32(0); i < count; i++{
+		e, err := readEntry(br)
+		if err != nil{
+			return nil, fmt.Errorf("%w: entry %d: %v", ErrInvalidArchive, i, err)
+		}
+
+		if e.offset < int64(len(archiveMagic)) || e.size > int64(dirOffset)-e.offset{
+			return nil, fmt.Errorf("%w: entry %q is out of range", ErrInvalidArchive, e.name)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if br.Len() != 0{
+		return nil, fmt.Errorf("%w: %d bytes after the directory", ErrInvalidArchive, br.Len())
+	}
+
+	return entries, nil
+}
+
+func readEntry(br *bytes.Reader) (entry, error){
+	var e entry
+
+	nameLen, err := binary.ReadUvarint(br)
+	if err != nil{
+		return e, err
+	}
+	if nameLen > uint64(br.Len()){
+		return e, io.ErrUnexpectedEOF
+	}
+
+	name := make([]byte, nameLen)
+	if _, err := io.ReadFull(br, name); err != nil{
+		return e, err
+	}
+	e.name = string(name)
+
+	mode, err := binary.ReadUvarint(br)
+	if err != nil{
+		return e, err
+	}
+	modTime, err := binary.ReadVarint(br)
+	if err != nil{
+		return e, err
+	}
+	e.mode, e.modTime = fs.FileMode(mode), time.Unix(0, modTime)
+
+	// raw size, offset and encoded size
+	var sizes [3]uint64
+	for i := range sizes{
+		if sizes[i], err = binary.ReadUvarint(br); err != nil{
+			return e, err
+		}
+		if sizes[i] > math.MaxInt64{
+			return e, fmt.Errorf("size %d is too large", sizes[i])
+		}
+	}
+	e.rawSize, e.offset, e.size = int64(sizes[0]), int64(sizes[1]), int64(sizes[2])
+
+	return e, nil
+}
+
+// IsArchive reports whether data starts as multi-file archive
+func IsArchive(data []byte) bool{
+	return bytes.HasPrefix(data, []byte(archiveMagic))
+}
@@ -0,0 +1,358 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"archiver/lib/compression"
+)
+
+// maxLinks is the number of symlinks followed while resolving a name, as in Linux
+const maxLinks = 40
+
+var ErrLinkOutside = errors.New("symlink points outside of the archive")
+var ErrLinkLoop = errors.New("too many levels of symlinks")
+
+// FS is a read-only file system over multi-file archive,
+// files are decoded when they are opened, symlinks are followed inside the archive
+type FS struct{
+	r io.ReaderAt
+	decoder compression.Decoder
+	entries map[string]*entry
+	children map[string][]*entry
+}
+
+var _ fs.ReadDirFS = (*FS)(nil)
+var _ fs.StatFS = (*FS)(nil)
+
+// NewFS reads the directory of archive of the given size,
+// decoder must match the encoder the archive is written with
+func NewFS(r io.ReaderAt, size int64, decoder compression.Decoder) (*FS, error){
+	entries, err := readDirectory(r, size)
+	if err != nil{
+		return nil, err
+	}
+
+	fsys := &FS{
+		r: r,
+		decoder: decoder,
+		entries: map[string]*entry{".": {name: ".", mode: fs.ModeDir | 0555}},
+		children: make(map[string][]*entry),
+	}
+
+	seen := make(map[string]bool)
+
+	for i := range entries{
+		e := &entries[i]
+
+		if !fs.ValidPath(e.name) || e.name == "."{
+			return nil, fmt.Errorf("%w: %q", ErrInvalidName, e.name)
+		}
+		if seen[e.name]{
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateName, e.name)
+		}
+		seen[e.name] = true
+
+		if err := fsys.add(e); err != nil{
+			return nil, err
+		}
+	}
+
+	for _, children := range fsys.children{
+		sort.Slice(children, func(i, j int) bool {
+			return children[i].name < children[j].name
+		})
+	}
+
+	return fsys, nil
+}
+
+// add adds e and its missing parent directories
+func (fsys *FS) add(e *entry) error{
+	if existing, ok := fsys.entries[e.name]; ok{
+		// only a directory may be added after its implicit directory
+		if !existing.mode.IsDir() || !e.mode.IsDir(){
+			return fmt.Errorf("%w: %q is a file and a directory", ErrDuplicateName, e.name)
+		}
+		existing.mode, existing.modTime = e.mode, e.modTime
+
+		return nil
+	}
+
+	dir := path.Dir(e.name)
+	parent, ok := fsys.entries[dir]
+	if !ok{
+		parent = &entry{name: dir, mode: fs.ModeDir | 0555}
+		if err := fsys.add(parent); err != nil{
+			return err
+		}
+	}
+	if !parent.mode.IsDir(){
+		return fmt.Errorf("%w: %q is a file and a directory", ErrDuplicateName, dir)
+	}
+
+	fsys.entries[e.name] = e
+	fsys.children[dir] = append(fsys.children[dir], e)
+
+	return nil
+}
+
+// lookup returns the entry of name following symlinks in its elements,
+// the last element is followed only if follow is set
+func (fsys *FS) lookup(op, name string, follow bool) (*entry, error){
+	if !fs.ValidPath(name){
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	// cur is the resolved directory, elems are left to resolve in it
+	cur := "."
+	elems := splitPath(name)
+	links := 0
+
+	for len(elems) > 0{
+		next := path.Join(cur, elems[0])
+		elems = elems[1:]
+
+		e, ok := fsys.entries[next]
+		if !ok || (len(elems) > 0 && !e.mode.IsDir() && e.mode&fs.ModeSymlink == 0){
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+
+		if e.mode&fs.ModeSymlink == 0 || (len(elems) == 0 && !follow){
+			cur = next
+			continue
+		}
+
+		links++
+		if links > maxLinks{
+			return nil, &fs.PathError{Op: op, Path: name, Err: ErrLinkLoop}
+		}
+
+		target, err := fsys.readFile(e)
+		if err != nil{
+			return nil, &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		// the target is relative to the directory of the link
+		resolved := path.Join(cur, string(target))
+		if path.IsAbs(string(target)) || !fs.ValidPath(resolved){
+			return nil, &fs.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %q", ErrLinkOutside, target)}
+		}
+
+		cur = "."
+		elems = append(splitPath(resolved), elems...)
+	}
+
+	return fsys.entries[cur], nil
+}
+
+// splitPath returns elements of valid path, . has no elements
+func splitPath(name string) []string{
+	if name == "."{
+		return nil
+	}
+
+	return strings.Split(name, "/")
+}
+
+// Open decodes the file, directories are opened without decoding
+func (fsys *FS) Open(name string) (fs.File, error){
+	e, err := fsys.lookup("open", name, true)
+	if err != nil{
+		return nil, err
+	}
+
+	if e.mode.IsDir(){
+		return &dir{info: fileInfo{e}, children: fsys.children[e.name]}, nil
+	}
+
+	data, err := fsys.readFile(e)
+	if err != nil{
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &file{info: fileInfo{e}, Reader: bytes.NewReader(data)}, nil
+}
+
+// ReadFile implements fs.ReadFileFS
+func (fsys *FS) ReadFile(name string) ([]byte, error){
+	e, err := fsys.lookup("read", name, true)
+	if err != nil{
+		return nil, err
+	}
+
+	if e.mode.IsDir(){
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := fsys.readFile(e)
+	if err != nil{
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	return data, nil
+}
+
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error){
+	e, err := fsys.lookup("readdir", name, true)
+	if err != nil{
+		return nil, err
+	}
+
+	if !e.mode.IsDir(){
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return dirEntries(fsys.children[e.name]), nil
+}
+
+// ReadLink returns the target of the symlink, it is stored as the contents of the link
+func (fsys *FS) ReadLink(name string) (string, error){
+	e, err := fsys.lookup("readlink", name, false)
+	if err != nil{
+		return "", err
+	}
+
+	if e.mode&fs.ModeSymlink == 0{
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := fsys.readFile(e)
+	if err != nil{
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	return string(data), nil
+}
+
+// Stat follows symlinks, Lstat describes the link itself
+func (fsys *FS) Stat(name string) (fs.FileInfo, error){
+	e, err := fsys.lookup("stat", name, true)
+	if err != nil{
+		return nil, err
+	}
+
+	return fileInfo{e}, nil
+}
+
+func (fsys *FS) Lstat(name string) (fs.FileInfo, error){
+	e, err := fsys.lookup("lstat", name, false)
+	if err != nil{
+		return nil, err
+	}
+
+	return fileInfo{e}, nil
+}
+
+func (fsys *FS) readFile(e *entry) ([]byte, error){
+	encoded := make([]byte, e.size)
+	if _, err := fsys.r.ReadAt(encoded, e.offset); err != nil{
+		return nil, err
+	}
+
+	decoded, err := fsys.decoder.Decode(encoded)
+	if err != nil{
+		return nil, err
+	}
+
+	if int64(len(decoded)) != e.rawSize{
+		return nil, fmt.Errorf("%w: %d bytes decoded, want %d", ErrInvalidArchive, len(decoded), e.rawSize)
+	}
+
+	return []byte(decoded), nil
+}
+
+
+// file is an opened file, it supports seeking for http.FileServer
+type file struct{
+	info fileInfo
+	*bytes.Reader
+}
+
+func (f *file) Stat() (fs.FileInfo, error){
+	return f.info, nil
+}
+
+func (f *file) Close() error{
+	return nil
+}
+
+
+// dir is an opened directory
+type dir struct{
+	info fileInfo
+	children []*entry
+	read int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error){
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error){
+	return 0, &fs.PathError{Op: "read", Path: d.info.e.name, Err: fs.ErrInvalid}
+}
+
+func (d *dir) Close() error{
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error){
+	rest := d.children[d.read:]
+
+	if n > 0{
+		if len(rest) == 0{
+			return nil, io.EOF
+		}
+		rest = rest[:min(n, len(rest))]
+	}
+	d.read += len(rest)
+
+	return dirEntries(rest), nil
+}
+
+func dirEntries(entries []*entry) []fs.DirEntry{
+	res := make([]fs.DirEntry, len(entries))
+	for i, e := range entries{
+		res[i] = fs.FileInfoToDirEntry(fileInfo{e})
+	}
+
+	return res
+}
+
+
+type fileInfo struct{
+	e *entry
+}
+
+func (fi fileInfo) Name() string{
+	return path.Base(fi.e.name)
+}
+
+func (fi fileInfo) Size() int64{
+	return fi.e.rawSize
+}
+
+func (fi fileInfo) Mode() fs.FileMode{
+	return fi.e.mode
+}
+
+func (fi fileInfo) ModTime() time.Time{
+	return fi.e.modTime
+}
+
+func (fi fileInfo) IsDir() bool{
+	return fi.e.mode.IsDir()
+}
+
+func (fi fileInfo) Sys() any{
+	return nil
+}
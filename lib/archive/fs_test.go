@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"testing"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"testing/fstest"
+	"time"
+
+	"archiver/lib/compression/vlc"
+	"archiver/lib/compression/vlc/table/haffman"
+)
+
+var modTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func mustArchive(t* testing.T, files map[string]string) []byte{
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, vlc.New(haffman.NewGenerator()))
+
+	for _, name := range []string{"index.html", "static", "static/css/site.css", "static/app.js", "empty.txt"}{
+		data, ok := files[name]
+		if !ok{
+			continue
+		}
+
+		mode := fs.FileMode(0644)
+		if name == "static"{
+			mode = fs.ModeDir | 0755
+		}
+		if err := w.WriteFile(name, []byte(data), mode, modTime); err != nil{
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil{
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFS(t* testing.T){
+	files := map[string]string{
+		"index.html": "<html>привет</html>",
+		"static": "",
+		"static/css/site.css": "body { margin: 0 }",
+		"static/app.js": "console.log('hi')",
+		"empty.txt": "",
+	}
+
+	data := mustArchive(t, files)
+
+	fsys, err := NewFS(bytes.NewReader(data), int64(len(data)), vlc.New(haffman.NewGenerator()))
+	if err != nil{
+		t.Fatalf("NewFS() error = %v", err)
+	}
+
+	if err := fstest.TestFS(fsys, "index.html", "static/css/site.css", "static/app.js", "empty.txt"); err != nil{
+		t.Fatal(err)
+	}
+
+	for name, want := range files{
+		if name == "static"{
+			continue
+		}
+
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil || string(got) != want{
+			t.Errorf("ReadFile(%q) = #%q %v#, want #%q#", name, got, err, want)
+		}
+	}
+
+	info, err := fs.Stat(fsys, "static")
+	if err != nil || !info.IsDir() || info.Mode().Perm() != 0755 || !info.ModTime().Equal(modTime){
+		t.Errorf("Stat(static) = #%v %v#, want explicit directory", info, err)
+	}
+
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist){
+		t.Errorf("Open() error = #%v#, want #%v#", err, fs.ErrNotExist)
+	}
+}
+
+func TestWriter_Errors(t* testing.T){
+	tests := []struct{
+		name string
+		names []string
+		wantErr error
+	}{
+		{name: "invalid name", names: []string{"../etc/passwd"}, wantErr: ErrInvalidName},
+		{name: "absolute name", names: []string{"/etc/passwd"}, wantErr: ErrInvalidName},
+		{name: "duplicate name", names: []string{"a.txt", "a.txt"}, wantErr: ErrDuplicateName},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			w := NewWriter(&bytes.Buffer{}, vlc.New(haffman.NewGenerator()))
+
+			var err error
+			for _, name := range tt.names{
+				err = w.WriteFile(name, []byte("data"), 0644, modTime)
+			}
+
+			if !errors.Is(err, tt.wantErr){
+				t.Errorf("WriteFile() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewFS_Errors(t* testing.T){
+	data := mustArchive(t, map[string]string{"index.html": "<html></html>"})
+
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "no trailer", data: data[:len(data)-1]},
+		{name: "truncated", data: data[1:]},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			_, err := NewFS(bytes.NewReader(tt.data), int64(len(tt.data)), vlc.New(haffman.NewGenerator()))
+			if !errors.Is(err, ErrInvalidArchive){
+				t.Errorf("NewFS() error = #%v#, want #%v#", err, ErrInvalidArchive)
+			}
+		})
+	}
+}
+
+// rawArchive returns archive of empty entries with names not checked by Writer
+func rawArchive(names ...string) []byte{
+	entries := make([]entry, len(names))
+	for i, name := range names{
+		entries[i] = entry{name: name, mode: 0644, modTime: modTime, offset: int64(len(archiveMagic))}
+	}
+
+	data := append([]byte(archiveMagic), encodeDirectory(entries)...)
+	data = binary.BigEndian.AppendUint64(data, uint64(len(archiveMagic)))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(entries)))
+
+	return append(data, archiveMagic...)
+}
+
+func TestNewFS_Malicious(t* testing.T){
+	tests := []struct{
+		name string
+		names []string
+		wantErr error
+	}{
+		{name: "parent directory", names: []string{"../../etc/passwd"}, wantErr: ErrInvalidName},
+		{name: "absolute path", names: []string{"/etc/passwd"}, wantErr: ErrInvalidName},
+		{name: "dot dot inside", names: []string{"a/../../b"}, wantErr: ErrInvalidName},
+		{name: "duplicate", names: []string{"a", "a"}, wantErr: ErrDuplicateName},
+		{name: "file and directory", names: []string{"a", "a/b"}, wantErr: ErrDuplicateName},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			data := rawArchive(tt.names...)
+
+			_, err := NewFS(bytes.NewReader(data), int64(len(data)), vlc.New(haffman.NewGenerator()))
+			if !errors.Is(err, tt.wantErr){
+				t.Errorf("NewFS() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFS_ReadLink(t* testing.T){
+	var buf bytes.Buffer
+	w := NewWriter(&buf, vlc.New(haffman.NewGenerator()))
+
+	if err := w.WriteFile("a.txt", []byte("a"), 0644, modTime); err != nil{
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := w.WriteFile("link", []byte("a.txt"), fs.ModeSymlink | 0777, modTime); err != nil{
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := w.Close(); err != nil{
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	fsys, err := NewFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()), vlc.New(haffman.NewGenerator()))
+	if err != nil{
+		t.Fatalf("NewFS() error = %v", err)
+	}
+
+	if got, err := fsys.ReadLink("link"); err != nil || got != "a.txt"{
+		t.Errorf("ReadLink() = #%q %v#, want #%q#", got, err, "a.txt")
+	}
+	if _, err := fsys.ReadLink("a.txt"); !errors.Is(err, fs.ErrInvalid){
+		t.Errorf("ReadLink() error = #%v#, want #%v#", err, fs.ErrInvalid)
+	}
+}
+
+func TestFS_Symlinks(t* testing.T){
+	var buf bytes.Buffer
+	w := NewWriter(&buf, vlc.New(haffman.NewGenerator()))
+
+	files := []struct{
+		name string
+		data string
+		mode fs.FileMode
+	}{
+		{name: "a.txt", data: "a", mode: 0644},
+		{name: "dir/b.txt", data: "b", mode: 0644},
+		{name: "link", data: "a.txt", mode: fs.ModeSymlink | 0777},
+		{name: "dir/up", data: "../link", mode: fs.ModeSymlink | 0777},
+		{name: "dirlink", data: "dir", mode: fs.ModeSymlink | 0777},
+		{name: "outside", data: "../a.txt", mode: fs.ModeSymlink | 0777},
+		{name: "absolute", data: "/etc/passwd", mode: fs.ModeSymlink | 0777},
+		{name: "loop1", data: "loop2", mode: fs.ModeSymlink | 0777},
+		{name: "loop2", data: "loop1", mode: fs.ModeSymlink | 0777},
+	}
+	for _, f := range files{
+		if err := w.WriteFile(f.name, []byte(f.data), f.mode, modTime); err != nil{
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil{
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	fsys, err := NewFS(bytes.NewReader(buf.Bytes()), int64(buf.Len()), vlc.New(haffman.NewGenerator()))
+	if err != nil{
+		t.Fatalf("NewFS() error = %v", err)
+	}
+
+	tests := []struct{
+		name string
+		want string
+		wantErr error
+	}{
+		{name: "link", want: "a"},
+		{name: "dir/up", want: "a"},
+		{name: "dirlink/b.txt", want: "b"},
+		{name: "dirlink/up", want: "a"},
+		{name: "outside", wantErr: ErrLinkOutside},
+		{name: "absolute", wantErr: ErrLinkOutside},
+		{name: "loop1", wantErr: ErrLinkLoop},
+		{name: "link/a.txt", wantErr: fs.ErrNotExist},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, err := fsys.ReadFile(tt.name)
+			if !errors.Is(err, tt.wantErr) || string(got) != tt.want{
+				t.Errorf("ReadFile() = #%q %v#, want #%q %v#", got, err, tt.want, tt.wantErr)
+			}
+
+			f, err := fsys.Open(tt.name)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("Open() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if err == nil{
+				f.Close()
+			}
+		})
+	}
+
+	if info, err := fsys.Stat("dirlink"); err != nil || !info.IsDir(){
+		t.Errorf("Stat() = #%v %v#, want a directory", info, err)
+	}
+	if info, err := fsys.Lstat("dirlink"); err != nil || info.Mode()&fs.ModeSymlink == 0{
+		t.Errorf("Lstat() = #%v %v#, want a symlink", info, err)
+	}
+	if got, err := fsys.ReadLink("dirlink/up"); err != nil || got != "../link"{
+		t.Errorf("ReadLink() = #%q %v#, want #%q#", got, err, "../link")
+	}
+}
@@ -0,0 +1,126 @@
+package bench
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"archiver/lib/compression"
+)
+
+// memorySampling is the interval of heap size sampling
+const memorySampling = time.Millisecond
+
+var ErrMismatch = errors.New("decoded data doesn't match the original")
+
+// Result is the measurement of method on file
+type Result struct{
+	Method string
+	File string
+	RawSize int64
+	Size int64
+	Compress time.Duration
+	Decompress time.Duration
+	// PeakMemory is the peak heap growth during compression or decompression
+	PeakMemory uint64
+	Err error
+}
+
+// Ratio returns compressed size relative to the original size
+func (r Result) Ratio() float64{
+	if r.RawSize == 0{
+		return 0
+	}
+
+	return float64(r.Size) / float64(r.RawSize)
+}
+
+// CompressSpeed returns compression speed in MB/s of the original data
+func (r Result) CompressSpeed() float64{
+	return speed(r.RawSize, r.Compress)
+}
+
+// DecompressSpeed returns decompression speed in MB/s of the original data
+func (r Result) DecompressSpeed() float64{
+	return speed(r.RawSize, r.Decompress)
+}
+
+// Run encodes and decodes file with method and checks the result
+func Run(m compression.Method, f File) Result{
+	res := Result{Method: m.Name, File: f.Name, RawSize: int64(len(f.Data))}
+	codec := m.New()
+
+	var encoded []byte
+	var decoded string
+
+	peak := measurePeak(func(){
+		start := time.Now()
+		encoded, res.Err = codec.Encode(string(f.Data))
+		res.Compress = time.Since(start)
+
+		if res.Err != nil{
+			return
+		}
+
+		start = time.Now()
+		decoded, res.Err = codec.Decode(encoded)
+		res.Decompress = time.Since(start)
+	})
+
+	res.Size, res.PeakMemory = int64(len(encoded)), peak
+
+	if res.Err == nil && decoded != string(f.Data){
+		res.Err = ErrMismatch
+	}
+
+	return res
+}
+
+// measurePeak calls f and returns the peak heap growth sampled during the call
+func measurePeak(f func()) uint64{
+	var stats runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	base, peak := stats.HeapAlloc, stats.HeapAlloc
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func(){
+		defer wg.Done()
+
+		ticker := time.NewTicker(memorySampling)
+		defer ticker.Stop()
+
+		var stats runtime.MemStats
+		for{
+			select{
+				case <-done:
+					return
+				case <-ticker.C:
+					runtime.ReadMemStats(&stats)
+					peak = max(peak, stats.HeapAlloc)
+			}
+		}
+	}()
+
+	f()
+	close(done)
+	wg.Wait()
+
+	runtime.ReadMemStats(&stats)
+	peak = max(peak, stats.HeapAlloc)
+
+	return peak - base
+}
+
+func speed(size int64, d time.Duration) float64{
+	if d <= 0{
+		return 0
+	}
+
+	return float64(size) / (1 << 20) / d.Seconds()
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"testing"
+	"reflect"
+
+	"archiver/lib/compression"
+	_ "archiver/lib/compression/codecs"
+)
+
+func TestSyntheticCorpus(t* testing.T){
+	corpus := SyntheticCorpus(10000)
+
+	if len(corpus) != 5{
+		t.Fatalf("SyntheticCorpus() = %d files, want 5", len(corpus))
+	}
+	for _, f := range corpus{
+		if len(f.Data) < 10000 || len(f.Data) > 11000{
+			t.Errorf("SyntheticCorpus() %s size = %d, want about 10000", f.Name, len(f.Data))
+		}
+	}
+
+	if !reflect.DeepEqual(corpus, SyntheticCorpus(10000)){
+		t.Errorf("SyntheticCorpus() differs for the same size")
+	}
+}
+
+func TestRun(t* testing.T){
+	corpus := SyntheticCorpus(20000)
+
+	tests := []struct{
+		method string
+		file File
+		wantErr bool
+	}{
+		{method: "haffman", file: corpus[0]},
+		{method: "lz", file: corpus[1]},
+		{method: "rice", file: corpus[4]},
+		{method: "rice", file: corpus[0], wantErr: true},
+	}
+	for _, tt := range tests{
+		t.Run(tt.method + "/" + tt.file.Name, func(t* testing.T){
+			m, err := compression.Lookup(tt.method)
+			if err != nil{
+				t.Fatal(err)
+			}
+
+			res := Run(m, tt.file)
+			if (res.Err != nil) != tt.wantErr{
+				t.Fatalf("Run() error = %v, wantErr %v", res.Err, tt.wantErr)
+			}
+			if tt.wantErr{
+				return
+			}
+
+			if res.Ratio() <= 0 || res.Ratio() >= 1{
+				t.Errorf("Run() ratio = %v, want 0-1", res.Ratio())
+			}
+			if res.CompressSpeed() <= 0 || res.DecompressSpeed() <= 0{
+				t.Errorf("Run() speed = %v %v, want positive", res.CompressSpeed(), res.DecompressSpeed())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is a corpus file
+type File struct{
+	Name string
+	Data []byte
+}
+
+// words are ordered by frequency of English text
+var words = strings.Fields(`the of and to a in is it you that he was for on are with as his they
+be at one have this from or had by hot word but what some we can out other were all there when up
+use your how said an each she which do their time if will way about many then them write would like
+so these her long make thing see him two has look more day could go come did number sound no most
+people my over know water than call first who may down side been now find any new work part take get
+place made live where after back little only round man year came show every good me give our under
+name very through just form sentence great think say help low line differ turn cause much mean before
+move right boy old too same tell does set three want air well also play small end put home read hand
+port large spell add even land here must big high such follow act why ask men change went light kind`)
+
+// SyntheticCorpus generates files similar to common corpora of about size bytes each:
+// English-like text, server logs, CSV table, source code and numbers.
+// The corpus is the same for the same size.
+func SyntheticCorpus(size int) []File{
+	rnd := rand.New(rand.NewSource(1))
+
+	return []File{
+		{Name: "text.txt", Data: generate(size, func(buf *bytes.Buffer){ writeSentence(buf, rnd) })},
+		{Name: "server.log", Data: generate(size, func(buf *bytes.Buffer){ writeLogLine(buf, rnd) })},
+		{Name: "table.csv", Data: generate(size, func(buf *bytes.Buffer){ writeCSVRow(buf, rnd) })},
+		{Name: "source.go", Data: generate(size, func(buf *bytes.Buffer){ writeFunction(buf, rnd) })},
+		{Name: "numbers.txt", Data: generate(size, func(buf *bytes.Buffer){ fmt.Fprintf(buf, "%d\n", rnd.Intn(1000)) })},
+	}
+}
+
+// ReadCorpus reads regular files of dir
+func ReadCorpus(dir string) ([]File, error){
+	var res []File
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error{
+		if err != nil{
+			return err
+		}
+		if !d.Type().IsRegular(){
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil{
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil{
+			return err
+		}
+		res = append(res, File{Name: filepath.ToSlash(name), Data: data})
+
+		return nil
+	})
+
+	return res, err
+}
+
+// generate calls write until size bytes are written,
+// the last record isn't cut
+func generate(size int, write func(buf *bytes.Buffer)) []byte{
+	var buf bytes.Buffer
+	for buf.Len() < size{
+		write(&buf)
+	}
+
+	return buf.Bytes()
+}
+
+// word returns a word with Zipf-like distribution
+func word(rnd *rand.Rand) string{
+	return words[int(float64(len(words)) * rnd.Float64() * rnd.Float64())]
+}
+
+func capitalize(w string) string{
+	return strings.ToUpper(w[:1]) + w[1:]
+}
+
+func writeSentence(buf *bytes.Buffer, rnd *rand.Rand){
+	n := 4 + rnd.Intn(12)
+	for i := 0; i < n; i++{
+		w := word(rnd)
+		if i == 0{
+			w = capitalize(w)
+		}
+		buf.WriteString(w)
+
+		if i < n-1{
+			buf.WriteByte(' ')
+		}
+	}
+
+	buf.WriteString(". ")
+	if rnd.Intn(6) == 0{
+		buf.WriteString("\n\n")
+	}
+}
+
+func writeLogLine(buf *bytes.Buffer, rnd *rand.Rand){
+	levels := []string{"INFO", "INFO", "INFO", "DEBUG", "WARN", "ERROR"}
+	paths := []string{"/api/users", "/api/orders", "/health", "/api/cart", "/static/app.js"}
+
+	fmt.Fprintf(buf, "2025-03-%02d %02d:%02d:%02d.%03d %-5s request_id=%08x method=GET path=%s status=%d duration=%dms\n",
+		1+rnd.Intn(28), rnd.Intn(24), rnd.Intn(60), rnd.Intn(60), rnd.Intn(1000),
+		levels[rnd.Intn(len(levels))], rnd.Uint32(), paths[rnd.Intn(len(paths))],
+		[]int{200, 200, 200, 201, 404, 500}[rnd.Intn(6)], rnd.Intn(500))
+}
+
+func writeCSVRow(buf *bytes.Buffer, rnd *rand.Rand){
+	fmt.Fprintf(buf, "%d,%s %s,%s,%d.%02d,%t\n",
+		rnd.Intn(100000), word(rnd), word(rnd), []string{"EU", "US", "ASIA"}[rnd.Intn(3)],
+		rnd.Intn(1000), rnd.Intn(100), rnd.Intn(2) == 0)
+}
+
+func writeFunction(buf *bytes.Buffer, rnd *rand.Rand){
+	name := word(rnd) + capitalize(word(rnd))
+
+	fmt.Fprintf(buf, "// %s returns the %s of %s\nfunc %s(%s []int) (int, error){\n", name, word(rnd), word(rnd), name, word(rnd))
+	fmt.Fprintf(buf, "\tres := 0\n\tfor i := 0; i < len(%s); i++{\n\t\tif %s[i] > %d{\n\t\t\treturn 0, errors.New(%q)\n\t\t}\n",
+		"data", "data", rnd.Intn(100), word(rnd) + " " + word(rnd))
+	fmt.Fprintf(buf, "\t\tres += data[i]\n\t}\n\n\treturn res, nil\n}\n\n")
+}
@@ -0,0 +1,188 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// AutoMethod is the name selecting the method by estimates, it can't be registered
+const AutoMethod = "auto"
+
+const (
+	// sampleBlocks blocks are taken evenly from data to estimate methods
+	sampleBlocks = 8
+	sampleBlockSize = 64 << 10
+)
+
+var ErrNoMethod = errors.New("no method can encode the data")
+var ErrRoundTrip = errors.New("sample decodes differently")
+var ErrNoLines = errors.New("sample has no whole lines")
+
+// Estimate is the compressed size of data estimated by encoding samples,
+// Err is set if the method can't encode the data or decode it back
+type Estimate struct{
+	Method Method
+	Size int64
+	Err error
+	// sample is the encoded part of data
+	sample string
+	// encoded is the encoded sample
+	encoded []byte
+}
+
+// EstimateMethods estimates compressed size of str for every registered method
+// encoding input of the kind, estimates are ordered from the best,
+// failed methods are the last. Numbers methods encode text of whole lines,
+// they are estimated on whole lines of str.
+func EstimateMethods(str string, input Input) []Estimate{
+	blocks, lines := Sample(str), SampleLines(str)
+
+	var res []Estimate
+	for _, m := range Methods(){
+		if m.Input != Bytes && input != Text{
+			continue
+		}
+		e := Estimate{Method: m, sample: blocks}
+		if m.Input == Numbers{
+			e.sample = lines
+		}
+		sample := e.sample
+
+		if len(sample) == 0 && len(str) > 0{
+			e.Err = ErrNoLines
+			res = append(res, e)
+			continue
+		}
+
+		encoded, err := m.New().Encode(sample)
+		e.encoded = encoded
+		if err != nil{
+			e.Err = err
+		} else if len(sample) > 0{
+			e.Size = int64(float64(len(encoded)) * float64(len(str)) / float64(len(sample)))
+		} else{
+			e.Size = int64(len(encoded))
+		}
+
+		res = append(res, e)
+	}
+
+	sortEstimates(res)
+
+	return res
+}
+
+// SelectMethod returns the method with the smallest estimate and all estimates,
+// the method is confirmed to decode the sample back, methods which don't are failed.
+// Numbers methods estimated on a part of str are confirmed to encode all of it,
+// as lines out of the sample may be not numbers.
+func SelectMethod(str string, input Input) (Method, []Estimate, error){
+	estimates := EstimateMethods(str, input)
+
+	for i := range estimates{
+		e := &estimates[i]
+		if e.Err != nil{
+			break
+		}
+
+		decoded, err := e.Method.New().Decode(e.encoded)
+		switch{
+			case err != nil:
+				e.Err = fmt.Errorf("%w: %w", ErrRoundTrip, err)
+				continue
+			case decoded != e.sample:
+				e.Err = ErrRoundTrip
+				continue
+		}
+
+		if e.Method.Input == Numbers && len(e.sample) != len(str){
+			if _, err := e.Method.New().Encode(str); err != nil{
+				e.Err = err
+				continue
+			}
+		}
+
+		return e.Method, estimates, nil
+	}
+
+	sortEstimates(estimates)
+
+	return Method{}, estimates, ErrNoMethod
+}
+
+// sortEstimates orders estimates from the best, failed methods are the last
+func sortEstimates(estimates []Estimate){
+	sort.SliceStable(estimates, func(i, j int) bool {
+		if (estimates[i].Err == nil) != (estimates[j].Err == nil){
+			return estimates[i].Err == nil
+		}
+
+		return estimates[i].Size < estimates[j].Size
+	})
+}
+
+// SampleLines returns whole lines taken evenly from str as Sample does, or str if it is small,
+// lines longer than a block aren't sampled
+func SampleLines(str string) string{
+	if len(str) <= sampleBlocks * sampleBlockSize{
+		return str
+	}
+
+	var buf strings.Builder
+	step := len(str) / sampleBlocks
+
+	for i := 0; i < sampleBlocks; i++{
+		start := i * step
+		if start > 0{
+			// the block starts at the next line
+			next := strings.IndexByte(str[start-1:], '\n')
+			if next < 0{
+				break
+			}
+			start += next
+		}
+
+		end := min(start + sampleBlockSize, len(str))
+		if end < len(str){
+			last := strings.LastIndexByte(str[start:end], '\n')
+			if last < 0{
+				continue
+			}
+			end = start + last + 1
+		}
+
+		buf.WriteString(str[start:end])
+	}
+
+	return buf.String()
+}
+
+// Sample returns blocks taken evenly from str, or str if it is small,
+// blocks start at character boundaries
+func Sample(str string) string{
+	if len(str) <= sampleBlocks * sampleBlockSize{
+		return str
+	}
+
+	var buf strings.Builder
+	step := len(str) / sampleBlocks
+
+	for i := 0; i < sampleBlocks; i++{
+		start := i * step
+		for start < len(str) && !utf8.RuneStart(str[start]){
+			start++
+		}
+
+		end := min(start + sampleBlockSize, len(str))
+		for end < len(str) && !utf8.RuneStart(str[end]){
+			end++
+		}
+
+		buf.WriteString(str[start:end])
+	}
+
+	return buf.String()
+}
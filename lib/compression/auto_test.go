@@ -0,0 +1,72 @@
+package compression
+
+import (
+	"testing"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// lossyCodec encodes everything into nothing
+type lossyCodec struct{}
+
+func (lossyCodec) Encode(str string) ([]byte, error){
+	return nil, nil
+}
+
+func (lossyCodec) Decode(codes []byte) (string, error){
+	return "", nil
+}
+
+func TestSelectMethod_RoundTrip(t* testing.T){
+	registerTestCodecs()
+
+	m, estimates, err := SelectMethod("text to pack", Text)
+	if err != nil || m.Name == "test_lossy"{
+		t.Fatalf("SelectMethod() = #%v %v#, want a method decoding the sample back", m.Name, err)
+	}
+
+	for _, e := range estimates{
+		if e.Method.Name == "test_lossy" && !errors.Is(e.Err, ErrRoundTrip){
+			t.Errorf("SelectMethod() test_lossy error = #%v#, want #%v#", e.Err, ErrRoundTrip)
+		}
+	}
+}
+
+func TestSample(t* testing.T){
+	small := "small text"
+	if got := Sample(small); got != small{
+		t.Errorf("Sample() = #%q#, want #%q#", got, small)
+	}
+
+	big := strings.Repeat("пакет ", sampleBlocks * sampleBlockSize)
+
+	got := Sample(big)
+	if len(got) < sampleBlocks * sampleBlockSize || len(got) > sampleBlocks * (sampleBlockSize + utf8.UTFMax){
+		t.Errorf("Sample() size = %d, want about %d", len(got), sampleBlocks * sampleBlockSize)
+	}
+	if !utf8.ValidString(got){
+		t.Errorf("Sample() splits characters")
+	}
+}
+
+func TestSampleLines(t* testing.T){
+	small := "1\n2\n"
+	if got := SampleLines(small); got != small{
+		t.Errorf("SampleLines() = #%q#, want #%q#", got, small)
+	}
+
+	big := strings.Repeat("12345\n", sampleBlocks * sampleBlockSize / 3)
+
+	got := SampleLines(big)
+	if len(got) < sampleBlocks * (sampleBlockSize - 6) || len(got) > sampleBlocks * sampleBlockSize{
+		t.Errorf("SampleLines() size = %d, want about %d", len(got), sampleBlocks * sampleBlockSize)
+	}
+	if lines := strings.SplitAfter(got, "\n"); lines[len(lines)-1] != "" || strings.Count(got, "12345\n") != len(lines)-1{
+		t.Errorf("SampleLines() splits lines")
+	}
+
+	if got := SampleLines(strings.Repeat("1", sampleBlocks * sampleBlockSize + 1)); got != ""{
+		t.Errorf("SampleLines() of a long line = %d bytes, want none", len(got))
+	}
+}
@@ -0,0 +1,49 @@
+// Package codecs registers the built-in compression methods,
+// import it for side effects:
+//
+//	import _ "archiver/lib/compression/codecs"
+package codecs
+
+import (
+	"archiver/lib/compression"
+	"archiver/lib/compression/intcode"
+	"archiver/lib/compression/lz"
+	"archiver/lib/compression/tunstall"
+	"archiver/lib/compression/vlc"
+	"archiver/lib/compression/vlc/table/alphabetic"
+	"archiver/lib/compression/vlc/table/haffman"
+	"archiver/lib/compression/vlc/table/shanon_fano"
+)
+
+// header IDs of built-in methods, they must never change
+const (
+	ShanonFanoID byte = iota + 1
+	HaffmanID
+	AlphabeticID
+	RiceID
+	LZID
+	TunstallID
+)
+
+func init(){
+	compression.Register("shanon_fano", ShanonFanoID, compression.Text, func() compression.EncoderDecoder{
+		return vlc.New(shanon_fano.NewGenerator())
+	})
+	compression.Register("haffman", HaffmanID, compression.Text, func() compression.EncoderDecoder{
+		return vlc.New(haffman.NewGenerator())
+	})
+	compression.Register("alphabetic", AlphabeticID, compression.Text, func() compression.EncoderDecoder{
+		return vlc.New(alphabetic.NewGenerator())
+	})
+	compression.Register("rice", RiceID, compression.Numbers, func() compression.EncoderDecoder{
+		return intcode.NewRiceCoder()
+	})
+	compression.Register("lz", LZID, compression.Bytes, func() compression.EncoderDecoder{
+		return lz.New()
+	})
+	compression.Register("tunstall", TunstallID, compression.Text, func() compression.EncoderDecoder{
+		// the dictionary is stored in the file, codeword size matters for encoding only
+		g, _ := tunstall.NewGenerator(tunstall.DefaultCodewordSize)
+		return tunstall.New(g)
+	})
+}
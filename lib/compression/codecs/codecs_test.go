@@ -0,0 +1,104 @@
+package codecs
+
+import (
+	"testing"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"archiver/lib/compression"
+)
+
+func TestBuiltinMethods(t* testing.T){
+	want := []string{"shanon_fano", "haffman", "alphabetic", "rice", "lz", "tunstall"}
+
+	names := compression.Names()
+	if len(names) < len(want){
+		t.Fatalf("Names() = #%v#, want #%v#", names, want)
+	}
+
+	for i, name := range want{
+		if names[i] != name{
+			t.Errorf("Names()[%d] = #%v#, want #%v#", i, names[i], name)
+		}
+	}
+
+	for _, m := range compression.Methods(){
+		t.Run(m.Name, func(t* testing.T){
+			str := "1\n22\n333\n"
+
+			encoded, err := m.New().Encode(str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			decoded, err := m.New().Decode(encoded)
+			if err != nil || decoded != str{
+				t.Errorf("Decode() = #%q %v#, want #%q#", decoded, err, str)
+			}
+		})
+	}
+}
+
+func TestSelectMethod(t* testing.T){
+	tests := []struct{
+		name string
+		str string
+		input compression.Input
+		want string
+		wantEstimates []string
+	}{
+		{name: "repeated text", str: strings.Repeat("the same line of a log\n", 5000), input: compression.Text, want: "lz", wantEstimates: []string{"shanon_fano", "haffman", "alphabetic", "rice", "lz", "tunstall"}},
+		{name: "repeated numbers", str: strings.Repeat("3\n1\n4\n1\n5\n9\n2\n6\n5\n3\n5\n", 1000), input: compression.Text, want: "lz", wantEstimates: []string{"shanon_fano", "haffman", "alphabetic", "rice", "lz", "tunstall"}},
+		{name: "numbers", str: randomNumbers(20000), input: compression.Text, want: "rice", wantEstimates: []string{"shanon_fano", "haffman", "alphabetic", "rice", "lz", "tunstall"}},
+		{name: "filtered bytes", str: strings.Repeat("\xe8\x00\x01\x02\x03", 100), input: compression.Bytes, want: "lz", wantEstimates: []string{"lz"}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			m, estimates, err := compression.SelectMethod(tt.str, tt.input)
+			if err != nil || m.Name != tt.want{
+				t.Errorf("SelectMethod() = #%v %v#, want #%v#", m.Name, err, tt.want)
+			}
+
+			var names []string
+			for _, e := range estimates{
+				names = append(names, e.Method.Name)
+			}
+			sort.Strings(names)
+			sort.Strings(tt.wantEstimates)
+			if !reflect.DeepEqual(names, tt.wantEstimates){
+				t.Errorf("SelectMethod() estimates = #%v#, want #%v#", names, tt.wantEstimates)
+			}
+		})
+	}
+}
+
+// randomNumbers returns n small numbers, one per line
+func randomNumbers(n int) string{
+	r := rand.New(rand.NewSource(1))
+
+	var buf strings.Builder
+	for i := 0; i < n; i++{
+		buf.WriteString(strconv.Itoa(r.Intn(16)) + "\n")
+	}
+
+	return buf.String()
+}
+
+func TestSelectMethod_NumbersOutOfSample(t* testing.T){
+	str := randomNumbers(400000)
+	// the line is between the first two sampled blocks
+	i := strings.IndexByte(str[len(str)/16:], '\n') + len(str)/16 + 1
+	str = str[:i] + "not a number\n" + str[i:]
+
+	m, _, err := compression.SelectMethod(str, compression.Text)
+	if err != nil || m.Name == "rice"{
+		t.Errorf("SelectMethod() = #%v %v#, want a method encoding all lines", m.Name, err)
+	}
+
+	if _, err := m.New().Encode(str); err != nil{
+		t.Errorf("Encode() error = %v", err)
+	}
+}
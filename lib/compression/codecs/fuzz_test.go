@@ -0,0 +1,89 @@
+package codecs
+
+import (
+	"testing"
+	"encoding/binary"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/intcode"
+)
+
+// fuzzSeeds are inputs every method is fuzzed with
+var fuzzSeeds = []string{
+	"",
+	"a",
+	"1\n22\n333\n",
+	"My name is Ted. Меня зовут Тед.",
+	"abracadabra abracadabra abracadabra",
+	"\x00\x01\x02\xff",
+}
+
+// FuzzDecode checks that decoders of all registered methods
+// return errors and never panic on arbitrary data
+func FuzzDecode(f *testing.F){
+	for _, m := range compression.Methods(){
+		for _, seed := range fuzzSeeds{
+			if encoded, err := m.New().Encode(seed); err == nil{
+				f.Add(m.ID, encoded)
+			}
+		}
+	}
+	f.Add(byte(TunstallID), tunstallBomb(60000))
+
+	f.Fuzz(func(t* testing.T, id byte, data []byte){
+		m, err := compression.LookupID(id)
+		if err != nil{
+			t.Skip()
+		}
+
+		// only the absence of panics is checked
+		_, _ = m.New().Decode(data)
+	})
+}
+
+// tunstallBomb returns a small tunstall dictionary of 20 bit codes and 2 symbols
+// which internal nodes are a chain of depth nodes, so its words are about depth symbols long
+func tunstallBomb(depth int) []byte{
+	w := intcode.NewBitWriter()
+	for i := 0; i < depth; i++{
+		w.WriteBit(1)
+	}
+	for i := 0; i < depth+2; i++{
+		w.WriteBit(0)
+	}
+
+	data := binary.AppendUvarint([]byte{20, 2, 'a', 'b'}, uint64(len(w.Bytes())))
+
+	return append(data, w.Bytes()...)
+}
+
+// FuzzRoundTrip checks that everything encoded by all registered methods
+// is decoded back byte for byte, methods reject what they can't encode exactly
+func FuzzRoundTrip(f *testing.F){
+	for _, m := range compression.Methods(){
+		for _, seed := range fuzzSeeds{
+			f.Add(m.ID, seed)
+		}
+	}
+
+	f.Fuzz(func(t* testing.T, id byte, str string){
+		m, err := compression.LookupID(id)
+		if err != nil{
+			t.Skip()
+		}
+
+		encoded, err := m.New().Encode(str)
+		if err != nil{
+			// e.g. rice encodes canonical lists of numbers only
+			t.Skip()
+		}
+
+		decoded, err := m.New().Decode(encoded)
+		if err != nil{
+			t.Fatalf("%s: Decode() error = %v", m.Name, err)
+		}
+		if decoded != str{
+			t.Errorf("%s: Decode() = #%q#, want #%q#", m.Name, decoded, str)
+		}
+	})
+}
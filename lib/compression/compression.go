@@ -1,10 +1,10 @@
 package compression
 
 type Encoder interface{
-	Encode(str string) []byte
+	Encode(str string) ([]byte, error)
 }
 
 
 type Decoder interface{
-	Decode(codes []byte) string
+	Decode(codes []byte) (string, error)
 }
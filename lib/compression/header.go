@@ -0,0 +1,61 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// headerMagic starts packed files, it is followed by the method ID
+const headerMagic = "GOAR"
+
+// filteredMagic starts files filtered before packing,
+// it is followed by the method ID and the filter ID
+const filteredMagic = "GOAF"
+
+// HeaderSize is the size of the header of packed files
+const HeaderSize = len(headerMagic) + 1
+
+// MaxHeaderSize is the size of the header of filtered files
+const MaxHeaderSize = HeaderSize + 1
+
+var ErrNoHeader = errors.New("file has no method header")
+var ErrInvalidHeader = errors.New("invalid method header")
+
+// AppendHeader appends the header of files packed with method m,
+// filterID is the filter applied before packing, 0 means no filter
+func AppendHeader(dst []byte, m Method, filterID byte) []byte{
+	if filterID == 0{
+		return append(append(dst, headerMagic...), m.ID)
+	}
+
+	return append(append(dst, filteredMagic...), m.ID, filterID)
+}
+
+// ReadHeader returns the method and the filter ID data is packed with and the data after the header.
+// Files packed before headers were introduced return ErrNoHeader.
+func ReadHeader(data []byte) (Method, byte, []byte, error){
+	filtered := bytes.HasPrefix(data, []byte(filteredMagic))
+
+	if len(data) < HeaderSize || !filtered && !bytes.HasPrefix(data, []byte(headerMagic)){
+		return Method{}, 0, data, ErrNoHeader
+	}
+
+	m, err := LookupID(data[len(headerMagic)])
+	if err != nil{
+		return Method{}, 0, data, err
+	}
+
+	if !filtered{
+		return m, 0, data[HeaderSize:], nil
+	}
+
+	switch{
+		case len(data) < MaxHeaderSize || data[HeaderSize] == 0:
+			return Method{}, 0, data, fmt.Errorf("%w: no filter ID", ErrInvalidHeader)
+		case m.Input != Bytes:
+			return Method{}, 0, data, fmt.Errorf("%w: %s can't be filtered", ErrInvalidHeader, m.Name)
+	}
+
+	return m, data[HeaderSize], data[MaxHeaderSize:], nil
+}
@@ -0,0 +1,78 @@
+package intcode
+
+import "errors"
+
+var ErrUnexpectedEnd = errors.New("unexpected end of bit stream")
+
+// BitWriter writes bits MSB first, the last byte is padded with zeros.
+type BitWriter struct{
+	buf []byte
+	bitsCount int
+}
+
+func NewBitWriter() *BitWriter{
+	return &BitWriter{}
+}
+
+func (w *BitWriter) WriteBit(bit uint){
+	if w.bitsCount%8 == 0{
+		w.buf = append(w.buf, 0)
+	}
+
+	if bit != 0{
+		w.buf[len(w.buf)-1] |= 1 << (7 - w.bitsCount%8)
+	}
+	w.bitsCount++
+}
+
+// WriteBits writes size low bits of v, the most significant first
+func (w *BitWriter) WriteBits(v uint64, size int){
+	for i := size - 1; i >= 0; i--{
+		w.WriteBit(uint(v>>i) & 1)
+	}
+}
+
+// Len returns the number of written bits
+func (w *BitWriter) Len() int{
+	return w.bitsCount
+}
+
+func (w *BitWriter) Bytes() []byte{
+	return w.buf
+}
+
+
+// BitReader reads bits written by BitWriter
+type BitReader struct{
+	data []byte
+	pos int
+}
+
+func NewBitReader(data []byte) *BitReader{
+	return &BitReader{data: data}
+}
+
+func (r *BitReader) ReadBit() (uint, error){
+	if r.pos >= len(r.data)*8{
+		return 0, ErrUnexpectedEnd
+	}
+
+	bit := uint(r.data[r.pos/8]>>(7 - r.pos%8)) & 1
+	r.pos++
+
+	return bit, nil
+}
+
+func (r *BitReader) ReadBits(size int) (uint64, error){
+	var res uint64
+
+	for i := 0; i < size; i++{
+		bit, err := r.ReadBit()
+		if err != nil{
+			return 0, err
+		}
+		res = res<<1 | uint64(bit)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,72 @@
+package intcode
+
+import (
+	"testing"
+	"reflect"
+	"errors"
+)
+
+func Test_BitWriter(t* testing.T){
+	tests := []struct{
+		name string
+		bits []uint
+		want []byte
+	}{
+		{
+			name: "empty",
+			bits: nil,
+			want: nil,
+		},
+		{
+			name: "padded byte",
+			bits: []uint{1, 0, 1},
+			want: []byte{0b10100000},
+		},
+		{
+			name: "two bytes",
+			bits: []uint{1, 1, 1, 1, 0, 0, 0, 0, 1},
+			want: []byte{0b11110000, 0b10000000},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			w := NewBitWriter()
+			for _, bit := range tt.bits{
+				w.WriteBit(bit)
+			}
+
+			if got := w.Bytes(); !reflect.DeepEqual(got, tt.want){
+				t.Errorf("Bytes() = #%08b#, want #%08b#", got, tt.want)
+			}
+			if w.Len() != len(tt.bits){
+				t.Errorf("Len() = #%v#, want #%v#", w.Len(), len(tt.bits))
+			}
+		})
+	}
+}
+
+func Test_BitReader(t* testing.T){
+	r := NewBitReader([]byte{0b10110011})
+
+	got, err := r.ReadBits(6)
+	if err != nil || got != 0b101100{
+		t.Errorf("ReadBits() = #%b %v#, want #101100 <nil>#", got, err)
+	}
+
+	if _, err := r.ReadBits(3); !errors.Is(err, ErrUnexpectedEnd){
+		t.Errorf("ReadBits() error = #%v#, want #%v#", err, ErrUnexpectedEnd)
+	}
+}
+
+// bitString returns written bits as '0' and '1' characters
+func bitString(w *BitWriter) string{
+	r := NewBitReader(w.Bytes())
+	res := make([]byte, 0, w.Len())
+
+	for i := 0; i < w.Len(); i++{
+		bit, _ := r.ReadBit()
+		res = append(res, byte('0' + bit))
+	}
+
+	return string(res)
+}
@@ -0,0 +1,135 @@
+package intcode
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var ErrOutOfRange = errors.New("number is out of the code range")
+var ErrInvalidCode = errors.New("invalid code")
+
+
+// WriteGamma writes Elias gamma code of n >= 1:
+// N zeros followed by N+1 bits of n, where N = floor(log2(n)),
+// i.g.: 5 -> 00101
+func WriteGamma(w *BitWriter, n uint64) error{
+	if n == 0{
+		return ErrOutOfRange
+	}
+
+	size := bits.Len64(n)
+	w.WriteBits(0, size-1)
+	w.WriteBits(n, size)
+
+	return nil
+}
+
+func ReadGamma(r *BitReader) (uint64, error){
+	zeros := 0
+
+	for{
+		bit, err := r.ReadBit()
+		if err != nil{
+			return 0, err
+		}
+		if bit == 1{
+			break
+		}
+
+		zeros++
+		if zeros >= 64{
+			return 0, ErrInvalidCode
+		}
+	}
+
+	rest, err := r.ReadBits(zeros)
+	if err != nil{
+		return 0, err
+	}
+
+	return 1<<zeros | rest, nil
+}
+
+
+// WriteDelta writes Elias delta code of n >= 1:
+// gamma code of the length of n followed by n without its leading 1,
+// i.g.: 5 -> 011 01
+func WriteDelta(w *BitWriter, n uint64) error{
+	if n == 0{
+		return ErrOutOfRange
+	}
+
+	size := bits.Len64(n)
+	if err := WriteGamma(w, uint64(size)); err != nil{
+		return err
+	}
+	w.WriteBits(n, size-1)
+
+	return nil
+}
+
+func ReadDelta(r *BitReader) (uint64, error){
+	size, err := ReadGamma(r)
+	if err != nil{
+		return 0, err
+	}
+	if size > 64{
+		return 0, ErrInvalidCode
+	}
+
+	rest, err := r.ReadBits(int(size) - 1)
+	if err != nil{
+		return 0, err
+	}
+
+	return 1<<(size-1) | rest, nil
+}
+
+
+// WriteOmega writes Elias omega code of n >= 1:
+// recursively encoded lengths followed by n and a terminating zero,
+// i.g.: 5 -> 10 101 0
+func WriteOmega(w *BitWriter, n uint64) error{
+	if n == 0{
+		return ErrOutOfRange
+	}
+
+	// groups are written in reverse order
+	var groups []uint64
+	for n > 1{
+		groups = append(groups, n)
+		n = uint64(bits.Len64(n) - 1)
+	}
+
+	for i := len(groups) - 1; i >= 0; i--{
+		w.WriteBits(groups[i], bits.Len64(groups[i]))
+	}
+	w.WriteBit(0)
+
+	return nil
+}
+
+func ReadOmega(r *BitReader) (uint64, error){
+	n := uint64(1)
+
+	for{
+		bit, err := r.ReadBit()
+		if err != nil{
+			return 0, err
+		}
+		if bit == 0{
+			return n, nil
+		}
+
+		if n >= 64{
+			return 0, ErrInvalidCode
+		}
+
+		// the group starts with the bit we have just read
+		rest, err := r.ReadBits(int(n))
+		if err != nil{
+			return 0, err
+		}
+		n = 1<<n | rest
+	}
+}
@@ -0,0 +1,80 @@
+package intcode
+
+import (
+	"testing"
+	"errors"
+	"math"
+)
+
+type eliasCode struct{
+	name string
+	write func(w *BitWriter, n uint64) error
+	read func(r *BitReader) (uint64, error)
+}
+
+var eliasCodes = []eliasCode{
+	{name: "gamma", write: WriteGamma, read: ReadGamma},
+	{name: "delta", write: WriteDelta, read: ReadDelta},
+	{name: "omega", write: WriteOmega, read: ReadOmega},
+}
+
+func Test_EliasCodes(t* testing.T){
+	tests := []struct{
+		name string
+		n uint64
+		want []string
+	}{
+		// gamma, delta, omega
+		{name: "1", n: 1, want: []string{"1", "1", "0"}},
+		{name: "2", n: 2, want: []string{"010", "0100", "100"}},
+		{name: "5", n: 5, want: []string{"00101", "01101", "101010"}},
+		{name: "17", n: 17, want: []string{"000010001", "001010001", "10100100010"}},
+	}
+	for _, tt := range tests{
+		for i, code := range eliasCodes{
+			t.Run(code.name + " " + tt.name, func(t* testing.T){
+				w := NewBitWriter()
+				if err := code.write(w, tt.n); err != nil{
+					t.Fatalf("write() error = %v", err)
+				}
+
+				if got := bitString(w); got != tt.want[i]{
+					t.Errorf("write() = #%v#, want #%v#", got, tt.want[i])
+				}
+			})
+		}
+	}
+}
+
+func Test_EliasCodes_RoundTrip(t* testing.T){
+	nums := []uint64{1, 2, 3, 4, 7, 8, 100, 1000, 1<<32, math.MaxUint64}
+
+	for _, code := range eliasCodes{
+		t.Run(code.name, func(t* testing.T){
+			w := NewBitWriter()
+			for _, n := range nums{
+				if err := code.write(w, n); err != nil{
+					t.Fatalf("write() error = %v", err)
+				}
+			}
+
+			r := NewBitReader(w.Bytes())
+			for _, want := range nums{
+				got, err := code.read(r)
+				if err != nil || got != want{
+					t.Errorf("read() = #%v %v#, want #%v#", got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_EliasCodes_Zero(t* testing.T){
+	for _, code := range eliasCodes{
+		t.Run(code.name, func(t* testing.T){
+			if err := code.write(NewBitWriter(), 0); !errors.Is(err, ErrOutOfRange){
+				t.Errorf("write() error = #%v#, want #%v#", err, ErrOutOfRange)
+			}
+		})
+	}
+}
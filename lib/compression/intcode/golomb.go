@@ -0,0 +1,156 @@
+package intcode
+
+import (
+	"math"
+	"math/bits"
+)
+
+// maxRiceParameter is the largest k worth trying:
+// bigger k never gives shorter codes for 64-bit numbers
+const maxRiceParameter = 63
+
+
+// WriteGolomb writes Golomb code of n >= 0 with parameter m >= 1:
+// quotient n/m in unary (ones terminated with zero)
+// and remainder n%m in truncated binary
+func WriteGolomb(w *BitWriter, n uint64, m uint64) error{
+	if m == 0{
+		return ErrOutOfRange
+	}
+
+	writeUnary(w, n/m)
+	writeTruncated(w, n%m, m)
+
+	return nil
+}
+
+func ReadGolomb(r *BitReader, m uint64) (uint64, error){
+	if m == 0{
+		return 0, ErrOutOfRange
+	}
+
+	q, err := readUnary(r)
+	if err != nil{
+		return 0, err
+	}
+
+	rem, err := readTruncated(r, m)
+	if err != nil{
+		return 0, err
+	}
+
+	return q*m + rem, nil
+}
+
+
+// WriteRice writes Rice code of n: Golomb code with m = 2^k,
+// the remainder is written with exactly k bits
+func WriteRice(w *BitWriter, n uint64, k int){
+	writeUnary(w, n>>k)
+	w.WriteBits(n, k)
+}
+
+func ReadRice(r *BitReader, k int) (uint64, error){
+	q, err := readUnary(r)
+	if err != nil{
+		return 0, err
+	}
+
+	rem, err := r.ReadBits(k)
+	if err != nil{
+		return 0, err
+	}
+
+	return q<<k | rem, nil
+}
+
+
+// RiceParameter returns k that gives the shortest Rice codes for nums
+func RiceParameter(nums []uint64) int{
+	best := 0
+	bestSize := uint64(0)
+
+	for k := 0; k <= maxRiceParameter; k++{
+		size := uint64(0)
+		for _, n := range nums{
+			size = saturatingAdd(size, n>>k + 1 + uint64(k))
+		}
+
+		if k == 0 || size < bestSize{
+			best = k
+			bestSize = size
+		}
+	}
+
+	return best
+}
+
+func saturatingAdd(a, b uint64) uint64{
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0{
+		return math.MaxUint64
+	}
+
+	return sum
+}
+
+
+func writeUnary(w *BitWriter, q uint64){
+	for ; q > 0; q--{
+		w.WriteBit(1)
+	}
+	w.WriteBit(0)
+}
+
+func readUnary(r *BitReader) (uint64, error){
+	q := uint64(0)
+
+	for{
+		bit, err := r.ReadBit()
+		if err != nil{
+			return 0, err
+		}
+		if bit == 0{
+			return q, nil
+		}
+		q++
+	}
+}
+
+// writeTruncated writes v < m with b-1 bits if v < 2^b - m and with b bits otherwise,
+// where b = ceil(log2(m))
+func writeTruncated(w *BitWriter, v uint64, m uint64){
+	size := bits.Len64(m - 1)
+	cutoff := uint64(1)<<size - m
+
+	if v < cutoff{
+		w.WriteBits(v, size-1)
+		return
+	}
+	w.WriteBits(v+cutoff, size)
+}
+
+func readTruncated(r *BitReader, m uint64) (uint64, error){
+	size := bits.Len64(m - 1)
+	cutoff := uint64(1)<<size - m
+
+	if size == 0{
+		return 0, nil
+	}
+
+	v, err := r.ReadBits(size - 1)
+	if err != nil{
+		return 0, err
+	}
+	if v < cutoff{
+		return v, nil
+	}
+
+	bit, err := r.ReadBit()
+	if err != nil{
+		return 0, err
+	}
+	v = v<<1 | uint64(bit)
+
+	return v - cutoff, nil
+}
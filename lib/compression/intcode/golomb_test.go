@@ -0,0 +1,115 @@
+package intcode
+
+import "testing"
+
+func Test_WriteGolomb(t* testing.T){
+	tests := []struct{
+		name string
+		n uint64
+		m uint64
+		want string
+	}{
+		{name: "m=1", n: 3, m: 1, want: "1110"},
+		{name: "m=3, short remainder", n: 3, m: 3, want: "100"},
+		{name: "m=3, long remainder", n: 5, m: 3, want: "10 11"},
+		{name: "m=10", n: 42, m: 10, want: "11110 010"},
+		{name: "m=10, long remainder", n: 9, m: 10, want: "0 1111"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			w := NewBitWriter()
+			if err := WriteGolomb(w, tt.n, tt.m); err != nil{
+				t.Fatalf("WriteGolomb() error = %v", err)
+			}
+
+			want := removeSpaces(tt.want)
+			if got := bitString(w); got != want{
+				t.Errorf("WriteGolomb() = #%v#, want #%v#", got, want)
+			}
+
+			got, err := ReadGolomb(NewBitReader(w.Bytes()), tt.m)
+			if err != nil || got != tt.n{
+				t.Errorf("ReadGolomb() = #%v %v#, want #%v#", got, err, tt.n)
+			}
+		})
+	}
+}
+
+func Test_Golomb_RoundTrip(t* testing.T){
+	for m := uint64(1); m <= 20; m++{
+		w := NewBitWriter()
+		for n := uint64(0); n < 100; n++{
+			if err := WriteGolomb(w, n, m); err != nil{
+				t.Fatalf("WriteGolomb() error = %v", err)
+			}
+		}
+
+		r := NewBitReader(w.Bytes())
+		for n := uint64(0); n < 100; n++{
+			if got, err := ReadGolomb(r, m); err != nil || got != n{
+				t.Fatalf("ReadGolomb(m = %d) = #%v %v#, want #%v#", m, got, err, n)
+			}
+		}
+	}
+}
+
+func Test_WriteRice(t* testing.T){
+	tests := []struct{
+		name string
+		n uint64
+		k int
+		want string
+	}{
+		{name: "k=0", n: 2, k: 0, want: "110"},
+		{name: "k=2", n: 9, k: 2, want: "110 01"},
+		{name: "k=3", n: 5, k: 3, want: "0 101"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			w := NewBitWriter()
+			WriteRice(w, tt.n, tt.k)
+
+			want := removeSpaces(tt.want)
+			if got := bitString(w); got != want{
+				t.Errorf("WriteRice() = #%v#, want #%v#", got, want)
+			}
+
+			got, err := ReadRice(NewBitReader(w.Bytes()), tt.k)
+			if err != nil || got != tt.n{
+				t.Errorf("ReadRice() = #%v %v#, want #%v#", got, err, tt.n)
+			}
+		})
+	}
+}
+
+func Test_RiceParameter(t* testing.T){
+	tests := []struct{
+		name string
+		nums []uint64
+		want int
+	}{
+		{name: "empty", nums: nil, want: 0},
+		{name: "zeros", nums: []uint64{0, 0, 0}, want: 0},
+		{name: "small", nums: []uint64{2, 3, 2, 3, 2}, want: 1},
+		{name: "around 100", nums: []uint64{90, 100, 110, 120}, want: 6},
+		{name: "huge", nums: []uint64{1 << 62, 1<<63 + 1}, want: 62},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if got := RiceParameter(tt.nums); got != tt.want{
+				t.Errorf("RiceParameter() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func removeSpaces(str string) string{
+	res := make([]byte, 0, len(str))
+	for i := 0; i < len(str); i++{
+		if str[i] != ' '{
+			res = append(res, str[i])
+		}
+	}
+
+	return string(res)
+}
@@ -0,0 +1,116 @@
+package intcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"archiver/lib/compression"
+)
+
+var ErrNotCanonical = errors.New("integer stream isn't canonical")
+
+// RiceCoder compresses integer stream files: non-negative decimal integers
+// without leading zeros, one per line, each line ends with \n.
+// Other texts would decode differently, so they aren't encoded.
+type RiceCoder struct{
+	limits compression.Limits
+}
+
+func NewRiceCoder() RiceCoder{
+	return RiceCoder{}
+}
+
+// WithLimits returns RiceCoder which checks the output while decoding
+func (rc RiceCoder) WithLimits(limits compression.Limits) RiceCoder{
+	rc.limits = limits
+
+	return rc
+}
+
+// Encode writes k, numbers count and Rice codes of numbers,
+// k is chosen to minimize the codes size
+func (rc RiceCoder) Encode(str string) ([]byte, error){
+	nums, err := parseNumbers(str)
+	if err != nil{
+		return nil, err
+	}
+
+	k := RiceParameter(nums)
+
+	w := NewBitWriter()
+	for _, n := range nums{
+		WriteRice(w, n, k)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteByte(byte(k))
+	buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(nums))))
+	buf.Write(w.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+func (rc RiceCoder) Decode(codes []byte) (string, error){
+	const headerSize = 5
+
+	if len(codes) < headerSize{
+		return "", ErrUnexpectedEnd
+	}
+
+	k := int(codes[0])
+	if k > maxRiceParameter{
+		return "", fmt.Errorf("%w: rice parameter %d", ErrInvalidCode, k)
+	}
+	count := binary.BigEndian.Uint32(codes[1:headerSize])
+
+	// every code has at least k+1 bits
+	if uint64(count) * uint64(k+1) > uint64(len(codes)-headerSize) * 8{
+		return "", fmt.Errorf("%w: %d numbers don't fit into %d bytes", ErrUnexpectedEnd, count, len(codes)-headerSize)
+	}
+
+	r := NewBitReader(codes[headerSize:])
+
+	var buf strings.Builder
+	for i := uint32(0); i < count; i++{
+		n, err := ReadRice(r, k)
+		if err != nil{
+			return "", err
+		}
+
+		buf.WriteString(strconv.FormatUint(n, 10))
+		buf.WriteByte('\n')
+
+		if err := rc.limits.CheckOutput(int64(len(codes)), int64(buf.Len())); err != nil{
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// parseNumbers parses lines of decimal numbers, it accepts only the text
+// Decode writes: no signs, leading zeros, spaces or missing final \n
+func parseNumbers(str string) ([]uint64, error){
+	res := make([]uint64, 0, strings.Count(str, "\n"))
+
+	for len(str) > 0{
+		line, rest, ok := strings.Cut(str, "\n")
+		if !ok{
+			return nil, fmt.Errorf("%w: no line end after %q", ErrNotCanonical, line)
+		}
+		str = rest
+
+		n, err := strconv.ParseUint(line, 10, 64)
+		if err != nil || strconv.FormatUint(n, 10) != line{
+			return nil, fmt.Errorf("%w: line %q", ErrNotCanonical, line)
+		}
+		res = append(res, n)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package intcode
+
+import (
+	"testing"
+	"errors"
+)
+
+func Test_RiceCoder_RoundTrip(t* testing.T){
+	tests := []struct{
+		name string
+		str string
+	}{
+		{
+			name: "empty",
+			str: "",
+		},
+		{
+			name: "run lengths",
+			str: "3\n1\n4\n1\n5\n9\n2\n6\n5\n3\n5\n",
+		},
+		{
+			name: "distances",
+			str: "0\n1024\n4000\n18446744073709551615\n",
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			rc := NewRiceCoder()
+
+			encoded, err := rc.Encode(tt.str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, err := rc.Decode(encoded)
+			if err != nil || got != tt.str{
+				t.Errorf("Decode() = #%v %v#, want #%v#", got, err, tt.str)
+			}
+		})
+	}
+}
+
+// Test_RiceCoder_NotCanonical checks that texts decoded differently aren't encoded
+func Test_RiceCoder_NotCanonical(t* testing.T){
+	tests := []struct{
+		name string
+		str string
+	}{
+		{name: "not a number", str: "1\ntwo\n3\n"},
+		{name: "spaces", str: "3 1 4\n"},
+		{name: "tab", str: "1024\t4000\n"},
+		{name: "no final line end", str: "1\n2"},
+		{name: "empty line", str: "1\n\n2\n"},
+		{name: "leading zero", str: "007\n"},
+		{name: "sign", str: "+1\n"},
+		{name: "CRLF", str: "1\r\n"},
+		{name: "overflow", str: "18446744073709551616\n"},
+		{name: "line end only", str: "\n"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := NewRiceCoder().Encode(tt.str); !errors.Is(err, ErrNotCanonical){
+				t.Errorf("Encode() error = #%v#, want #%v#", err, ErrNotCanonical)
+			}
+		})
+	}
+}
+
+func Test_RiceCoder_Errors(t* testing.T){
+	rc := NewRiceCoder()
+
+	if _, err := rc.Decode([]byte{0, 0, 0}); !errors.Is(err, ErrUnexpectedEnd){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrUnexpectedEnd)
+	}
+
+	// 2 numbers announced, only one written
+	if _, err := rc.Decode([]byte{0, 0, 0, 0, 2, 0b01111111}); !errors.Is(err, ErrUnexpectedEnd){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrUnexpectedEnd)
+	}
+}
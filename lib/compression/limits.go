@@ -0,0 +1,56 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrTooManySymbols = errors.New("code table has too many symbols")
+var ErrOutputTooLarge = errors.New("decoded data is too large")
+var ErrRatioTooLarge = errors.New("decoded data expands too much")
+var ErrMemoryLimit = errors.New("decoding needs too much memory")
+
+// Limits restricts decoding of untrusted data, zero fields are unlimited.
+// Decoders check sizes declared by the data before decoding
+// and the produced data while decoding.
+type Limits struct{
+	// MaxTableSymbols limits symbols of code tables and dictionaries stored in the data
+	MaxTableSymbols int
+	// MaxOutputSize limits the decoded size in bytes
+	MaxOutputSize int64
+	// MaxRatio limits the decoded size relative to the encoded size
+	MaxRatio float64
+	// MaxMemory limits memory the decoder is estimated to use at once
+	MaxMemory int64
+}
+
+// CheckSymbols checks the number of table symbols
+func (l Limits) CheckSymbols(n int) error{
+	if l.MaxTableSymbols > 0 && n > l.MaxTableSymbols{
+		return fmt.Errorf("%w: %d symbols, limit %d", ErrTooManySymbols, n, l.MaxTableSymbols)
+	}
+
+	return nil
+}
+
+// CheckOutput checks size of data decoded from encodedSize bytes
+func (l Limits) CheckOutput(encodedSize, size int64) error{
+	if l.MaxOutputSize > 0 && size > l.MaxOutputSize{
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrOutputTooLarge, size, l.MaxOutputSize)
+	}
+
+	if l.MaxRatio > 0 && float64(size) > l.MaxRatio * float64(max(encodedSize, 1)){
+		return fmt.Errorf("%w: %d bytes from %d bytes, limit %.1fx", ErrRatioTooLarge, size, encodedSize, l.MaxRatio)
+	}
+
+	return nil
+}
+
+// CheckMemory checks estimated memory size in bytes
+func (l Limits) CheckMemory(size int64) error{
+	if l.MaxMemory > 0 && size > l.MaxMemory{
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrMemoryLimit, size, l.MaxMemory)
+	}
+
+	return nil
+}
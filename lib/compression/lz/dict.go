@@ -0,0 +1,168 @@
+package lz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const dictMagic = "LZDC"
+
+const (
+	// dmerSize is the size of substrings frequencies are counted for
+	dmerSize = 8
+	// segmentSize is the size of pieces the dictionary is made of
+	segmentSize = 64
+)
+
+var ErrInvalidDict = errors.New("invalid dictionary")
+var ErrNoSamples = errors.New("samples are too small to train a dictionary")
+
+// Dictionary primes the match window, so small messages
+// can refer to data typical for them
+type Dictionary struct{
+	ID uint32
+	Data []byte
+}
+
+
+func NewDictionary(data []byte) Dictionary{
+	return Dictionary{ID: crc32.ChecksumIEEE(data), Data: data}
+}
+
+// TrainDictionary selects the most frequent segments of samples (COVER algorithm):
+// a segment scores the number of samples containing each of its distinct d-mers,
+// the best segment of every epoch is taken and its d-mers are not counted anymore.
+// The best segments are placed at the end of the dictionary, closer to the data.
+func TrainDictionary(samples [][]byte, size int) (Dictionary, error){
+	var data []byte
+	freqs := make(map[uint64]int)
+
+	for _, sample := range samples{
+		seen := make(map[uint64]bool)
+
+		for i := 0; i+dmerSize <= len(sample); i++{
+			dmer := binary.LittleEndian.Uint64(sample[i:])
+			if !seen[dmer]{
+				seen[dmer] = true
+				freqs[dmer]++
+			}
+		}
+		data = append(data, sample...)
+	}
+
+	if len(data) < dmerSize || size <= 0{
+		return Dictionary{}, ErrNoSamples
+	}
+
+	if len(data) <= size{
+		return NewDictionary(data), nil
+	}
+
+	epochs := max(size / segmentSize, 1)
+	epochSize := len(data) / epochs
+
+	res := make([]byte, size)
+	end := size
+	found := false
+
+	for epoch := 0; end > 0; epoch = (epoch + 1) % epochs{
+		if epoch == 0{
+			found = false
+		}
+
+		from := epoch * epochSize
+		seg := bestSegment(data[from:from+epochSize], freqs)
+
+		if seg == nil{
+			// nothing useful is left in any epoch
+			if epoch == epochs - 1 && !found{
+				break
+			}
+			continue
+		}
+		found = true
+
+		for i := 0; i+dmerSize <= len(seg); i++{
+			delete(freqs, binary.LittleEndian.Uint64(seg[i:]))
+		}
+
+		n := min(len(seg), end)
+		copy(res[end-n:end], seg[len(seg)-n:])
+		end -= n
+	}
+
+	return NewDictionary(res[end:]), nil
+}
+
+// bestSegment returns segment of data with the best score,
+// nil if all scores are zero
+func bestSegment(data []byte, freqs map[uint64]int) []byte{
+	dmersCount := len(data) - dmerSize + 1
+	if dmersCount <= 0{
+		return nil
+	}
+	// d-mers starting in a segment
+	window := max(min(segmentSize - dmerSize + 1, dmersCount), 1)
+
+	active := make(map[uint64]int)
+	score := 0
+	best, bestScore := 0, 0
+
+	for i := 0; i < dmersCount; i++{
+		dmer := binary.LittleEndian.Uint64(data[i:])
+		if active[dmer] == 0{
+			score += freqs[dmer]
+		}
+		active[dmer]++
+
+		if i >= window{
+			old := binary.LittleEndian.Uint64(data[i-window:])
+			active[old]--
+			if active[old] == 0{
+				score -= freqs[old]
+			}
+		}
+
+		if score > bestScore{
+			best, bestScore = max(i - window + 1, 0), score
+		}
+	}
+
+	if bestScore == 0{
+		return nil
+	}
+
+	return data[best:min(best+segmentSize, len(data))]
+}
+
+
+// MarshalBinary writes magic, ID and dictionary data
+func (d Dictionary) MarshalBinary() ([]byte, error){
+	var buf bytes.Buffer
+
+	buf.WriteString(dictMagic)
+	buf.Write(binary.BigEndian.AppendUint32(nil, d.ID))
+	buf.Write(d.Data)
+
+	return buf.Bytes(), nil
+}
+
+func (d *Dictionary) UnmarshalBinary(data []byte) error{
+	const headerSize = len(dictMagic) + 4
+
+	if len(data) < headerSize || string(data[:len(dictMagic)]) != dictMagic{
+		return fmt.Errorf("%w: bad magic", ErrInvalidDict)
+	}
+
+	d.ID = binary.BigEndian.Uint32(data[len(dictMagic):headerSize])
+	d.Data = append([]byte{}, data[headerSize:]...)
+
+	if crc32.ChecksumIEEE(d.Data) != d.ID{
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidDict)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package lz
+
+import (
+	"testing"
+	"errors"
+	"fmt"
+	"reflect"
+	"bytes"
+)
+
+func Test_TrainDictionary(t* testing.T){
+	var samples [][]byte
+	for i := 0; i < 200; i++{
+		samples = append(samples, []byte(fmt.Sprintf(
+			`{"time":"2025-01-%02dT10:00:00Z","level":"info","service":"billing","request_id":%d}`, i%28+1, i*7919)))
+	}
+
+	dict, err := TrainDictionary(samples, 256)
+	if err != nil{
+		t.Fatalf("TrainDictionary() error = %v", err)
+	}
+
+	if len(dict.Data) == 0 || len(dict.Data) > 256{
+		t.Fatalf("TrainDictionary() size = %d, want 1-256", len(dict.Data))
+	}
+	if !bytes.Contains(dict.Data, []byte(`"service":"billing"`)){
+		t.Errorf("TrainDictionary() = #%q#, want frequent segments", dict.Data)
+	}
+
+	msg := `{"time":"2025-02-01T10:00:00Z","level":"info","service":"billing","request_id":42}`
+	plain, _ := New().Encode(msg)
+	primed, _ := NewWithDictionary(dict).Encode(msg)
+
+	if len(primed) * 2 > len(plain){
+		t.Errorf("Encode() with dictionary = %d bytes, without = %d bytes", len(primed), len(plain))
+	}
+}
+
+func Test_TrainDictionary_Small(t* testing.T){
+	dict, err := TrainDictionary([][]byte{[]byte("small sample")}, 1024)
+	if err != nil || string(dict.Data) != "small sample"{
+		t.Errorf("TrainDictionary() = #%q %v#, want #small sample#", dict.Data, err)
+	}
+
+	if _, err := TrainDictionary(nil, 1024); !errors.Is(err, ErrNoSamples){
+		t.Errorf("TrainDictionary() error = #%v#, want #%v#", err, ErrNoSamples)
+	}
+}
+
+func Test_Dictionary_MarshalBinary(t* testing.T){
+	dict := NewDictionary([]byte("dictionary data"))
+
+	data, err := dict.MarshalBinary()
+	if err != nil{
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	var got Dictionary
+	if err := got.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(got, dict){
+		t.Errorf("UnmarshalBinary() = #%v %v#, want #%v#", got, err, dict)
+	}
+
+	data[len(data)-1]++
+	if err := got.UnmarshalBinary(data); !errors.Is(err, ErrInvalidDict){
+		t.Errorf("UnmarshalBinary() error = #%v#, want #%v#", err, ErrInvalidDict)
+	}
+}
@@ -0,0 +1,319 @@
+package lz
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"archiver/lib/compression"
+)
+
+const (
+	windowSize = 1 << 16
+	minMatch = 4
+	maxMatch = 1 << 12
+
+	hashBits = 15
+	maxChain = 32
+
+	// tokens are written in groups of 8 after a byte of flags:
+	// 1 for a match, 0 for a literal
+	groupSize = 8
+)
+
+// header flags
+const (
+	noDictionary byte = iota
+	withDictionary
+)
+
+var ErrInvalidData = errors.New("invalid lz data")
+var ErrDictRequired = errors.New("file is packed with a dictionary, dictionary is not specified")
+var ErrDictMismatch = errors.New("file is packed with another dictionary")
+
+// EncoderDecoder is LZ77 coder with a 64KB window,
+// the window may be primed with a dictionary.
+type EncoderDecoder struct{
+	dict *Dictionary
+	limits compression.Limits
+}
+
+func New() EncoderDecoder{
+	return EncoderDecoder{}
+}
+
+func NewWithDictionary(dict Dictionary) EncoderDecoder{
+	return EncoderDecoder{dict: &dict}
+}
+
+// WithLimits returns EncoderDecoder which checks the declared size before decoding
+func (ed EncoderDecoder) WithLimits(limits compression.Limits) EncoderDecoder{
+	ed.limits = limits
+
+	return ed
+}
+
+
+// Encode writes header: dictionary flag, dictionary ID if any and data size,
+// followed by groups of literals and matches (distance, length - minMatch)
+func (ed EncoderDecoder) Encode(str string) ([]byte, error){
+	prefix := ed.prefix()
+
+	var buf bytes.Buffer
+
+	if ed.dict != nil{
+		buf.WriteByte(withDictionary)
+		buf.Write(binary.BigEndian.AppendUint32(nil, ed.dict.ID))
+	}else{
+		buf.WriteByte(noDictionary)
+	}
+	buf.Write(binary.AppendUvarint(nil, uint64(len(str))))
+
+	data := make([]byte, 0, len(prefix) + len(str))
+	data = append(data, prefix...)
+	data = append(data, str...)
+
+	buf.Write(encodeTokens(data, len(prefix)))
+
+	return buf.Bytes(), nil
+}
+
+func (ed EncoderDecoder) Decode(codes []byte) (string, error){
+	r := bytes.NewReader(codes)
+
+	flag, err := r.ReadByte()
+	if err != nil{
+		return "", fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+
+	switch flag{
+		case noDictionary:
+		case withDictionary:
+			var id uint32
+			if err := binary.Read(r, binary.BigEndian, &id); err != nil{
+				return "", fmt.Errorf("%w: %w", ErrInvalidData, err)
+			}
+
+			switch{
+				case ed.dict == nil:
+					return "", ErrDictRequired
+				case ed.dict.ID != id:
+					return "", fmt.Errorf("%w: file dictionary %08x, given dictionary %08x", ErrDictMismatch, id, ed.dict.ID)
+			}
+		default:
+			return "", fmt.Errorf("%w: unknown flag %d", ErrInvalidData, flag)
+	}
+
+	size, err := binary.ReadUvarint(r)
+	if err != nil{
+		return "", fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+
+	prefix := []byte(nil)
+	if flag == withDictionary{
+		prefix = ed.prefix()
+	}
+
+	// matches are up to maxMatch bytes, so the size can be checked before decoding
+	if size > uint64(r.Len()) * maxMatch{
+		return "", fmt.Errorf("%w: %d bytes can't be decoded from %d bytes", ErrInvalidData, size, r.Len())
+	}
+	if err := ed.limits.CheckOutput(int64(len(codes)), int64(size)); err != nil{
+		return "", err
+	}
+	// the output with the prefix and its copy to string
+	if err := ed.limits.CheckMemory(2 * (int64(size) + int64(len(prefix)))); err != nil{
+		return "", err
+	}
+
+	out, err := decodeTokens(codes[len(codes)-r.Len():], prefix, size)
+	if err != nil{
+		return "", err
+	}
+
+	return string(out[len(prefix):]), nil
+}
+
+// prefix returns the part of dictionary which fits into the window
+func (ed EncoderDecoder) prefix() []byte{
+	if ed.dict == nil{
+		return nil
+	}
+
+	data := ed.dict.Data
+	if len(data) > windowSize{
+		data = data[len(data)-windowSize:]
+	}
+
+	return data
+}
+
+
+// encodeTokens encodes data[start:], data[:start] is only used for matches
+func encodeTokens(data []byte, start int) []byte{
+	var out []byte
+
+	flagsPos := 0
+	tokens := 0
+
+	addToken := func(match bool){
+		if tokens%groupSize == 0{
+			flagsPos = len(out)
+			out = append(out, 0)
+		}
+		if match{
+			out[flagsPos] |= 1 << (tokens % groupSize)
+		}
+		tokens++
+	}
+
+	m := newMatcher(data)
+	for i := 0; i < start; i++{
+		m.insert(i)
+	}
+
+	for i := start; i < len(data);{
+		length, dist := m.find(i)
+
+		if length < minMatch{
+			addToken(false)
+			out = append(out, data[i])
+
+			m.insert(i)
+			i++
+			continue
+		}
+
+		addToken(true)
+		out = binary.AppendUvarint(out, uint64(dist))
+		out = binary.AppendUvarint(out, uint64(length - minMatch))
+
+		for j := i; j < i+length; j++{
+			m.insert(j)
+		}
+		i += length
+	}
+
+	return out
+}
+
+// decodeTokens appends size decoded bytes to prefix
+func decodeTokens(codes []byte, prefix []byte, size uint64) ([]byte, error){
+	out := make([]byte, len(prefix), len(prefix) + int(min(size, uint64(len(codes)) * maxMatch)))
+	copy(out, prefix)
+
+	end := uint64(len(prefix)) + size
+	r := bytes.NewReader(codes)
+
+	for uint64(len(out)) < end{
+		flags, err := r.ReadByte()
+		if err != nil{
+			return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+		}
+
+		for bit := 0; bit < groupSize && uint64(len(out)) < end; bit++{
+			if flags&(1<<bit) == 0{
+				b, err := r.ReadByte()
+				if err != nil{
+					return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+				}
+				out = append(out, b)
+				continue
+			}
+
+			dist, err := binary.ReadUvarint(r)
+			if err != nil{
+				return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+			}
+			length, err := binary.ReadUvarint(r)
+			if err != nil{
+				return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+			}
+			length += minMatch
+
+			if dist == 0 || dist > uint64(len(out)) || length > maxMatch || uint64(len(out)) + length > end{
+				return nil, fmt.Errorf("%w: bad match %d/%d at %d", ErrInvalidData, dist, length, len(out))
+			}
+
+			// byte by byte, the match may overlap itself
+			from := len(out) - int(dist)
+			for j := 0; j < int(length); j++{
+				out = append(out, out[from+j])
+			}
+		}
+	}
+
+	return out, nil
+}
+
+
+// matcher finds the longest previous match with hash chains
+type matcher struct{
+	data []byte
+	head []int32
+	prev []int32
+}
+
+func newMatcher(data []byte) *matcher{
+	m := &matcher{
+		data: data,
+		head: make([]int32, 1<<hashBits),
+		prev: make([]int32, len(data)),
+	}
+
+	for i := range m.head{
+		m.head[i] = -1
+	}
+
+	return m
+}
+
+func (m *matcher) hash(i int) uint32{
+	v := binary.LittleEndian.Uint32(m.data[i:])
+	return (v * 2654435761) >> (32 - hashBits)
+}
+
+func (m *matcher) insert(i int){
+	if i+minMatch > len(m.data){
+		return
+	}
+
+	h := m.hash(i)
+	m.prev[i] = m.head[h]
+	m.head[h] = int32(i)
+}
+
+// find returns length and distance of the longest match for data[i:]
+func (m *matcher) find(i int) (int, int){
+	if i+minMatch > len(m.data){
+		return 0, 0
+	}
+
+	limit := min(len(m.data) - i, maxMatch)
+	bestLen, bestDist := 0, 0
+
+	cand := m.head[m.hash(i)]
+	for chain := 0; cand >= 0 && chain < maxChain; chain++{
+		dist := i - int(cand)
+		if dist > windowSize{
+			break
+		}
+
+		length := 0
+		for length < limit && m.data[int(cand)+length] == m.data[i+length]{
+			length++
+		}
+
+		if length > bestLen{
+			bestLen, bestDist = length, dist
+			if length == limit{
+				break
+			}
+		}
+
+		cand = m.prev[cand]
+	}
+
+	return bestLen, bestDist
+}
@@ -0,0 +1,129 @@
+package lz
+
+import (
+	"testing"
+	"errors"
+	"math/rand"
+	"strings"
+
+	"archiver/lib/compression"
+)
+
+func Test_RoundTrip(t* testing.T){
+	r := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	r.Read(random)
+
+	tests := []struct{
+		name string
+		str string
+	}{
+		{name: "empty", str: ""},
+		{name: "short", str: "abc"},
+		{name: "base test", str: "My name is Ted, my name is Ted"},
+		{name: "overlapping match", str: strings.Repeat("a", 10000)},
+		{name: "long text", str: strings.Repeat("abracadabra, ", 10000)},
+		{name: "binary", str: string(random)},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			ed := New()
+
+			encoded, err := ed.Encode(tt.str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			if got, err := ed.Decode(encoded); err != nil || got != tt.str{
+				t.Errorf("Decode() = #%.50q %v#, want #%.50q#", got, err, tt.str)
+			}
+		})
+	}
+}
+
+func Test_encodeTokens(t* testing.T){
+	// 4 literals and a match of 8 bytes at distance 4
+	want := []byte{0b10000, 'a', 'b', 'c', 'd', 4, 8 - minMatch}
+
+	if got := encodeTokens([]byte("abcdabcdabcd"), 0); string(got) != string(want){
+		t.Errorf("encodeTokens() = #%v#, want #%v#", got, want)
+	}
+}
+
+func Test_Dictionary(t* testing.T){
+	dict := NewDictionary([]byte(`{"level":"info","service":"billing","message":"`))
+	msg := `{"level":"info","service":"billing","message":"payment accepted"}`
+
+	plain, _ := New().Encode(msg)
+
+	ed := NewWithDictionary(dict)
+	encoded, err := ed.Encode(msg)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if len(encoded) >= len(plain){
+		t.Errorf("Encode() with dictionary = %d bytes, without = %d bytes", len(encoded), len(plain))
+	}
+
+	if got, err := ed.Decode(encoded); err != nil || got != msg{
+		t.Errorf("Decode() = #%v %v#, want #%v#", got, err, msg)
+	}
+
+	if _, err := New().Decode(encoded); !errors.Is(err, ErrDictRequired){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrDictRequired)
+	}
+
+	other := NewWithDictionary(NewDictionary([]byte("other")))
+	if _, err := other.Decode(encoded); !errors.Is(err, ErrDictMismatch){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrDictMismatch)
+	}
+}
+
+func Test_Decode_Errors(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "unknown flag", data: []byte{7, 0}},
+		{name: "truncated", data: []byte{noDictionary, 5, 0, 'a'}},
+		{name: "match before start", data: []byte{noDictionary, 8, 0b10, 'a', 2, 4}},
+		{name: "match after end", data: []byte{noDictionary, 4, 0b10, 'a', 1, 4}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := New().Decode(tt.data); !errors.Is(err, ErrInvalidData){
+				t.Errorf("Decode() error = #%v#, want #%v#", err, ErrInvalidData)
+			}
+		})
+	}
+}
+
+func Test_Decode_Limits(t* testing.T){
+	// a small bomb: long runs are encoded with a few bytes per 4KB
+	bomb, err := New().Encode(strings.Repeat("0", 1 << 20))
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct{
+		name string
+		data []byte
+		limits compression.Limits
+		wantErr error
+	}{
+		{name: "output size", data: bomb, limits: compression.Limits{MaxOutputSize: 1 << 19}, wantErr: compression.ErrOutputTooLarge},
+		{name: "ratio", data: bomb, limits: compression.Limits{MaxRatio: 100}, wantErr: compression.ErrRatioTooLarge},
+		{name: "memory", data: bomb, limits: compression.Limits{MaxMemory: 1 << 20}, wantErr: compression.ErrMemoryLimit},
+		{name: "declared size", data: []byte{noDictionary, 0xff, 0xff, 0xff, 0x7f, 0xff}, wantErr: ErrInvalidData},
+		{name: "within limits", data: bomb, limits: compression.Limits{MaxOutputSize: 1 << 20, MaxRatio: 2000, MaxMemory: 4 << 20}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := New().WithLimits(tt.limits).Decode(tt.data); !errors.Is(err, tt.wantErr){
+				t.Errorf("Decode() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package compression
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var ErrUnknownMethod = errors.New("unknown method")
+
+// EncoderDecoder is a codec usable by both pack and unpack
+type EncoderDecoder interface{
+	Encoder
+	Decoder
+}
+
+// Factory returns codec with the default settings
+type Factory func() EncoderDecoder
+
+// Input is the kind of data a method encodes exactly, other data is rejected by Encode
+type Input int
+
+const (
+	// Bytes methods encode any data
+	Bytes Input = iota
+	// Text methods encode valid UTF-8 text
+	Text
+	// Numbers methods encode lists of numbers written as their Decode returns them
+	Numbers
+)
+
+// Method is a registered codec, ID is written to the header of packed files
+type Method struct{
+	Name string
+	ID byte
+	Input Input
+	New Factory
+}
+
+var (
+	methodsMu sync.RWMutex
+	methodsByName = make(map[string]Method)
+	methodsByID = make(map[byte]Method)
+)
+
+// Register makes codec encoding input available by name and header ID,
+// it is usually called from init of the package providing the codec.
+// Register panics if name or ID is already registered, name is AutoMethod or factory is nil.
+func Register(name string, id byte, input Input, factory Factory){
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+
+	if factory == nil{
+		panic("compression: Register factory is nil for " + name)
+	}
+	if name == AutoMethod{
+		panic("compression: Register name " + AutoMethod + " is reserved")
+	}
+	if _, ok := methodsByName[name]; ok{
+		panic("compression: Register called twice for " + name)
+	}
+	if m, ok := methodsByID[id]; ok{
+		panic(fmt.Sprintf("compression: Register ID %d of %s is used by %s", id, name, m.Name))
+	}
+
+	m := Method{Name: name, ID: id, Input: input, New: factory}
+	methodsByName[name] = m
+	methodsByID[id] = m
+}
+
+// Lookup returns method registered with name
+func Lookup(name string) (Method, error){
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+
+	m, ok := methodsByName[name]
+	if !ok{
+		return Method{}, fmt.Errorf("%w: %q, valid methods: %v", ErrUnknownMethod, name, names())
+	}
+
+	return m, nil
+}
+
+// LookupID returns method registered with header ID
+func LookupID(id byte) (Method, error){
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+
+	m, ok := methodsByID[id]
+	if !ok{
+		return Method{}, fmt.Errorf("%w: ID %d", ErrUnknownMethod, id)
+	}
+
+	return m, nil
+}
+
+// Methods returns registered methods ordered by ID
+func Methods() []Method{
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+
+	res := make([]Method, 0, len(methodsByID))
+	for _, m := range methodsByID{
+		res = append(res, m)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+// Names returns names of registered methods ordered by ID
+func Names() []string{
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+
+	return names()
+}
+
+func names() []string{
+	ids := make([]int, 0, len(methodsByID))
+	for id := range methodsByID{
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	res := make([]string, len(ids))
+	for i, id := range ids{
+		res[i] = methodsByID[byte(id)].Name
+	}
+
+	return res
+}
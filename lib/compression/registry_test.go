@@ -0,0 +1,110 @@
+package compression
+
+import (
+	"testing"
+	"errors"
+	"sync"
+)
+
+type copyCodec struct{}
+
+func (copyCodec) Encode(str string) ([]byte, error){
+	return []byte(str), nil
+}
+
+func (copyCodec) Decode(codes []byte) (string, error){
+	return string(codes), nil
+}
+
+func newCopyCodec() EncoderDecoder{
+	return copyCodec{}
+}
+
+var registerOnce sync.Once
+
+// registerTestCodecs registers codecs of tests once,
+// the registry is global and tests may run several times
+func registerTestCodecs(){
+	registerOnce.Do(func(){
+		Register("test_copy", 250, Bytes, newCopyCodec)
+		Register("test_header", 240, Bytes, newCopyCodec)
+		Register("test_text", 241, Text, newCopyCodec)
+		Register("test_lossy", 230, Text, func() EncoderDecoder{ return lossyCodec{} })
+		Register("test_exact", 231, Text, newCopyCodec)
+	})
+}
+
+func TestRegister(t* testing.T){
+	registerTestCodecs()
+
+	m, err := Lookup("test_copy")
+	if err != nil || m.ID != 250{
+		t.Errorf("Lookup() = #%v %v#, want ID 250", m, err)
+	}
+
+	if m, err := LookupID(250); err != nil || m.Name != "test_copy"{
+		t.Errorf("LookupID() = #%v %v#, want test_copy", m, err)
+	}
+
+	if _, err := Lookup("missing"); !errors.Is(err, ErrUnknownMethod){
+		t.Errorf("Lookup() error = #%v#, want #%v#", err, ErrUnknownMethod)
+	}
+	if _, err := LookupID(251); !errors.Is(err, ErrUnknownMethod){
+		t.Errorf("LookupID() error = #%v#, want #%v#", err, ErrUnknownMethod)
+	}
+
+	tests := []struct{
+		name string
+		methodName string
+		id byte
+		factory Factory
+	}{
+		{name: "duplicate name", methodName: "test_copy", id: 251, factory: newCopyCodec},
+		{name: "duplicate ID", methodName: "test_other", id: 250, factory: newCopyCodec},
+		{name: "nil factory", methodName: "test_nil", id: 252},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			defer func(){
+				if recover() == nil{
+					t.Errorf("Register() doesn't panic")
+				}
+			}()
+
+			Register(tt.methodName, tt.id, Bytes, tt.factory)
+		})
+	}
+}
+
+func TestReadHeader(t* testing.T){
+	registerTestCodecs()
+	m, _ := Lookup("test_header")
+	text, _ := Lookup("test_text")
+
+	tests := []struct{
+		name string
+		data []byte
+		want string
+		wantFilter byte
+		wantRest string
+		wantErr error
+	}{
+		{name: "method", data: append(AppendHeader(nil, m, 0), "payload"...), want: m.Name, wantRest: "payload"},
+		{name: "filtered", data: append(AppendHeader(nil, m, 2), "payload"...), want: m.Name, wantFilter: 2, wantRest: "payload"},
+		{name: "old file", data: []byte("old file"), wantRest: "old file", wantErr: ErrNoHeader},
+		{name: "unknown method", data: []byte(headerMagic + "\xff"), wantRest: headerMagic + "\xff", wantErr: ErrUnknownMethod},
+		{name: "no filter ID", data: []byte(filteredMagic + "\xf0"), wantRest: filteredMagic + "\xf0", wantErr: ErrInvalidHeader},
+		{name: "filtered text", data: AppendHeader(nil, text, 1), wantRest: string(AppendHeader(nil, text, 1)), wantErr: ErrInvalidHeader},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, filterID, rest, err := ReadHeader(tt.data)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("ReadHeader() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if got.Name != tt.want || filterID != tt.wantFilter || string(rest) != tt.wantRest{
+				t.Errorf("ReadHeader() = #%v %d %q#, want #%v %d %q#", got.Name, filterID, rest, tt.want, tt.wantFilter, tt.wantRest)
+			}
+		})
+	}
+}
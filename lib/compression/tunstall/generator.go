@@ -0,0 +1,234 @@
+package tunstall
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"archiver/lib/compression/vlc/table"
+)
+
+const (
+	MinCodewordSize = 1
+	MaxCodewordSize = 20
+	DefaultCodewordSize = 12
+	// MaxWordsLength limits the total length of dictionary words in symbols,
+	// decoders reject longer dictionaries
+	MaxWordsLength = 1 << 26
+)
+
+var ErrCodewordSize = errors.New("invalid codeword size")
+
+type Generator struct{
+	codewordSize int
+}
+
+// Dictionary is a complete tree of words:
+// every internal node has a child for every alphabet symbol,
+// leaves are words, leaf codes are their indexes in depth-first order.
+type Dictionary struct{
+	CodewordSize int
+	Alphabet []rune
+	root *node
+	leaves []*node
+	index map[rune]int
+}
+
+// node keeps the last symbol of its word, the word is rebuilt from parents,
+// so the dictionary memory is linear in the number of nodes
+type node struct{
+	prob float64
+	parent *node
+	symbol rune
+	depth int
+	children []*node
+	code uint32
+}
+
+// newChild returns the node of the word of n extended by ch
+func (n *node) newChild(ch rune) *node{
+	return &node{parent: n, symbol: ch, depth: n.depth + 1}
+}
+
+// appendWord appends the word of n to dst
+func (n *node) appendWord(dst []rune) []rune{
+	dst = slices.Grow(dst, n.depth)[:len(dst)+n.depth]
+
+	for cur, i := n, len(dst)-1; cur.parent != nil; cur, i = cur.parent, i-1{
+		dst[i] = cur.symbol
+	}
+
+	return dst
+}
+
+// leafQueue pops the most probable leaf first
+type leafQueue []*node
+
+func (q leafQueue) Len() int{
+	return len(q)
+}
+
+func (q leafQueue) Less(i, j int) bool{
+	if q[i].prob != q[j].prob{
+		return q[i].prob > q[j].prob
+	}
+	return lessNode(q[i], q[j])
+}
+
+func (q leafQueue) Swap(i, j int){
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *leafQueue) Push(x interface{}){
+	*q = append(*q, x.(*node))
+}
+
+func (q *leafQueue) Pop() interface{}{
+	old := *q
+	n := len(old)
+
+	item := old[n-1]
+	*q = old[0: n-1]
+
+	return item
+}
+
+
+// NewGenerator returns generator of dictionaries with 2^codewordSize words at most
+func NewGenerator(codewordSize int) (Generator, error){
+	if codewordSize < MinCodewordSize || codewordSize > MaxCodewordSize{
+		return Generator{}, fmt.Errorf("%w: %d, want %d-%d", ErrCodewordSize, codewordSize, MinCodewordSize, MaxCodewordSize)
+	}
+
+	return Generator{codewordSize: codewordSize}, nil
+}
+
+// NewDictionary builds Tunstall dictionary for text:
+// starting with single symbols, the most probable word is replaced
+// with its extensions by every symbol while the dictionary fits into the codeword size
+// and the words fit into MaxWordsLength
+func (g Generator) NewDictionary(text string) (Dictionary, error){
+	stat := table.NewHistogram(text, 0)
+
+	alphabet := make([]rune, 0, len(stat))
+	for ch := range stat{
+		alphabet = append(alphabet, ch)
+	}
+	sort.Slice(alphabet, func(i, j int) bool {
+		return alphabet[i] < alphabet[j]
+	})
+
+	maxWords := 1 << g.codewordSize
+	if len(alphabet) > maxWords{
+		return Dictionary{}, fmt.Errorf("%w: %d bits can't encode %d symbols", ErrCodewordSize, g.codewordSize, len(alphabet))
+	}
+
+	total := float64(len([]rune(text)))
+	probs := make([]float64, len(alphabet))
+	for i, ch := range alphabet{
+		probs[i] = float64(stat[ch]) / total
+	}
+
+	root := &node{prob: 1}
+	queue := &leafQueue{}
+
+	expand := func(n *node){
+		n.children = make([]*node, len(alphabet))
+
+		for i, ch := range alphabet{
+			child := n.newChild(ch)
+			child.prob = n.prob * probs[i]
+			n.children[i] = child
+			heap.Push(queue, child)
+		}
+	}
+
+	if len(alphabet) > 0{
+		expand(root)
+	}
+
+	// expanding a leaf adds len(alphabet) words and removes one,
+	// one symbol alphabet can't grow the dictionary
+	wordsCount := len(alphabet)
+	wordsLength := len(alphabet)
+	for len(alphabet) > 1 && wordsCount + len(alphabet) - 1 <= maxWords{
+		n := heap.Pop(queue).(*node)
+
+		// the word is replaced with len(alphabet) words one symbol longer
+		wordsLength += len(alphabet) * (n.depth + 1) - n.depth
+		if wordsLength > MaxWordsLength{
+			break
+		}
+
+		expand(n)
+		wordsCount += len(alphabet) - 1
+	}
+
+	return newDictionary(g.codewordSize, alphabet, root), nil
+}
+
+func newDictionary(codewordSize int, alphabet []rune, root *node) Dictionary{
+	d := Dictionary{
+		CodewordSize: codewordSize,
+		Alphabet: alphabet,
+		root: root,
+		index: make(map[rune]int, len(alphabet)),
+	}
+
+	for i, ch := range alphabet{
+		d.index[ch] = i
+	}
+
+	var walk func(n *node)
+	walk = func(n *node){
+		if n.children == nil{
+			n.code = uint32(len(d.leaves))
+			d.leaves = append(d.leaves, n)
+			return
+		}
+		for _, child := range n.children{
+			walk(child)
+		}
+	}
+	if len(alphabet) > 0{
+		walk(root)
+	}
+
+	return d
+}
+
+// Words returns dictionary words in the order of their codes
+func (d Dictionary) Words() []string{
+	res := make([]string, 0, len(d.leaves))
+
+	for _, leaf := range d.leaves{
+		res = append(res, string(leaf.appendWord(nil)))
+	}
+
+	return res
+}
+
+// lessNode compares the words of a and b of the same tree without building them:
+// a prefix is less than its extensions, other words differ
+// by the symbols of the children of their common ancestor
+func lessNode(a, b *node) bool{
+	x, y := a, b
+	for x.depth > y.depth{
+		x = x.parent
+	}
+	for y.depth > x.depth{
+		y = y.parent
+	}
+
+	if x == y{
+		return a.depth < b.depth
+	}
+
+	for x.parent != y.parent{
+		x, y = x.parent, y.parent
+	}
+
+	return x.symbol < y.symbol
+}
@@ -0,0 +1,103 @@
+package tunstall
+
+import (
+	"testing"
+	"reflect"
+	"errors"
+)
+
+func Test_NewDictionary(t* testing.T){
+	tests := []struct{
+		name string
+		codewordSize int
+		text string
+		want []string
+	}{
+		{
+			name: "empty text",
+			codewordSize: 2,
+			text: "",
+			want: []string{},
+		},
+		{
+			name: "single symbol",
+			codewordSize: 3,
+			text: "aaaa",
+			want: []string{"a"},
+		},
+		{
+			name: "base test",
+			codewordSize: 3,
+			// p(a) = 0.7, p(b) = 0.2, p(c) = 0.1
+			text: "aaaaaaabbc",
+			want: []string{"aaa", "aab", "aac", "ab", "ac", "b", "c"},
+		},
+		{
+			name: "equal probabilities",
+			codewordSize: 2,
+			text: "abab",
+			want: []string{"aa", "ab", "ba", "bb"},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			g, err := NewGenerator(tt.codewordSize)
+			if err != nil{
+				t.Fatalf("NewGenerator() error = %v", err)
+			}
+
+			dict, err := g.NewDictionary(tt.text)
+			if err != nil{
+				t.Fatalf("NewDictionary() error = %v", err)
+			}
+
+			if got := dict.Words(); !reflect.DeepEqual(got, tt.want){
+				t.Errorf("Words() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NewDictionary_Errors(t* testing.T){
+	if _, err := NewGenerator(0); !errors.Is(err, ErrCodewordSize){
+		t.Errorf("NewGenerator() error = #%v#, want #%v#", err, ErrCodewordSize)
+	}
+
+	g, _ := NewGenerator(1)
+	if _, err := g.NewDictionary("abc"); !errors.Is(err, ErrCodewordSize){
+		t.Errorf("NewDictionary() error = #%v#, want #%v#", err, ErrCodewordSize)
+	}
+}
+
+func Test_lessNode(t* testing.T){
+	g, _ := NewGenerator(6)
+	dict, err := g.NewDictionary("abcabc")
+	if err != nil{
+		t.Fatalf("NewDictionary() error = %v", err)
+	}
+
+	var nodes []*node
+	var walk func(n *node)
+	walk = func(n *node){
+		nodes = append(nodes, n)
+		for _, child := range n.children{
+			walk(child)
+		}
+	}
+	walk(dict.root)
+
+	for _, a := range nodes{
+		for _, b := range nodes{
+			wa, wb := string(a.appendWord(nil)), string(b.appendWord(nil))
+			if got := lessNode(a, b); got != (wa < wb){
+				t.Errorf("lessNode(%q, %q) = %v, want %v", wa, wb, got, wa < wb)
+			}
+		}
+	}
+
+	// ties of equally probable leaves are compared on every heap operation
+	q := leafQueue(dict.leaves)
+	if allocs := testing.AllocsPerRun(100, func(){ q.Less(0, len(q)-1) }); allocs != 0{
+		t.Errorf("Less() allocs = %v, want 0", allocs)
+	}
+}
@@ -0,0 +1,291 @@
+package tunstall
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/intcode"
+)
+
+var ErrInvalidData = errors.New("invalid tunstall data")
+var ErrInvalidText = errors.New("text is not valid UTF-8")
+
+// nodeSize is the estimated memory of a dictionary node and the pointer to it
+const nodeSize = 80
+
+type EncoderDecoder struct{
+	generator Generator
+	limits compression.Limits
+}
+
+func New(generator Generator) EncoderDecoder{
+	return EncoderDecoder{generator: generator}
+}
+
+// WithLimits returns EncoderDecoder which checks dictionary size and output while decoding
+func (ed EncoderDecoder) WithLimits(limits compression.Limits) EncoderDecoder{
+	ed.limits = limits
+
+	return ed
+}
+
+
+// Encode writes the dictionary, the tail of text which is shorter than any word
+// and fixed size codes of words:
+// codeword size | dictionary | tail | codes count | codes
+func (ed EncoderDecoder) Encode(str string) ([]byte, error){
+	if !utf8.ValidString(str){
+		return nil, ErrInvalidText
+	}
+
+	dict, err := ed.generator.NewDictionary(str)
+	if err != nil{
+		return nil, err
+	}
+
+	codes, tail := encodeWords(dict, str)
+
+	var buf bytes.Buffer
+
+	writeDictionary(&buf, dict)
+
+	buf.Write(binary.AppendUvarint(nil, uint64(len(tail))))
+	buf.WriteString(tail)
+
+	w := intcode.NewBitWriter()
+	for _, code := range codes{
+		w.WriteBits(uint64(code), dict.CodewordSize)
+	}
+
+	buf.Write(binary.AppendUvarint(nil, uint64(len(codes))))
+	buf.Write(w.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+func (ed EncoderDecoder) Decode(data []byte) (string, error){
+	r := bytes.NewReader(data)
+
+	dict, err := readDictionary(r, ed.limits)
+	if err != nil{
+		return "", err
+	}
+
+	tail, err := readBytes(r)
+	if err != nil{
+		return "", err
+	}
+
+	codesCount, err := binary.ReadUvarint(r)
+	if err != nil{
+		return "", fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+
+	rest := data[len(data)-r.Len():]
+	if codesCount > uint64(len(rest))*8{
+		return "", fmt.Errorf("%w: %d codes don't fit into %d bytes", ErrInvalidData, codesCount, len(rest))
+	}
+
+	br := intcode.NewBitReader(rest)
+	var buf strings.Builder
+	var word []rune
+
+	for i := uint64(0); i < codesCount; i++{
+		code, err := br.ReadBits(dict.CodewordSize)
+		if err != nil{
+			return "", fmt.Errorf("%w: %w", ErrInvalidData, err)
+		}
+		if code >= uint64(len(dict.leaves)){
+			return "", fmt.Errorf("%w: unknown code %d", ErrInvalidData, code)
+		}
+
+		word = dict.leaves[code].appendWord(word[:0])
+		for _, ch := range word{
+			buf.WriteRune(ch)
+		}
+
+		if err := ed.limits.CheckOutput(int64(len(data)), int64(buf.Len())); err != nil{
+			return "", err
+		}
+	}
+	buf.Write(tail)
+
+	return buf.String(), nil
+}
+
+
+// encodeWords splits text into dictionary words,
+// the rest of text that is a proper prefix of some word is returned as tail
+func encodeWords(d Dictionary, text string) ([]uint32, string){
+	var codes []uint32
+
+	cur := d.root
+	start := 0
+
+	for i, ch := range text{
+		cur = cur.children[d.index[ch]]
+
+		if cur.children == nil{
+			codes = append(codes, cur.code)
+			cur = d.root
+			start = i + utf8.RuneLen(ch)
+		}
+	}
+
+	return codes, text[start:]
+}
+
+
+// writeDictionary writes codeword size, alphabet
+// and the tree shape in depth-first order: 1 for internal nodes and 0 for leaves
+func writeDictionary(buf *bytes.Buffer, d Dictionary){
+	buf.WriteByte(byte(d.CodewordSize))
+
+	buf.Write(binary.AppendUvarint(nil, uint64(len(d.Alphabet))))
+	for _, ch := range d.Alphabet{
+		buf.Write(binary.AppendUvarint(nil, uint64(ch)))
+	}
+
+	w := intcode.NewBitWriter()
+
+	var walk func(n *node)
+	walk = func(n *node){
+		for _, child := range n.children{
+			if child.children == nil{
+				w.WriteBit(0)
+				continue
+			}
+			w.WriteBit(1)
+			walk(child)
+		}
+	}
+	walk(d.root)
+
+	buf.Write(binary.AppendUvarint(nil, uint64(len(w.Bytes()))))
+	buf.Write(w.Bytes())
+}
+
+// readDictionary reads the dictionary written by writeDictionary,
+// the number of words and memory are checked against limits before nodes are allocated
+func readDictionary(r *bytes.Reader, limits compression.Limits) (Dictionary, error){
+	codewordSize, err := r.ReadByte()
+	if err != nil{
+		return Dictionary{}, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	if codewordSize < MinCodewordSize || codewordSize > MaxCodewordSize{
+		return Dictionary{}, fmt.Errorf("%w: %w: %d", ErrInvalidData, ErrCodewordSize, codewordSize)
+	}
+	maxWords := 1 << codewordSize
+
+	alphabetSize, err := binary.ReadUvarint(r)
+	if err != nil{
+		return Dictionary{}, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	if alphabetSize > uint64(maxWords){
+		return Dictionary{}, fmt.Errorf("%w: %d symbols for %d bit codes", ErrInvalidData, alphabetSize, codewordSize)
+	}
+	// every symbol is a word or a prefix of words
+	if err := limits.CheckSymbols(int(alphabetSize)); err != nil{
+		return Dictionary{}, err
+	}
+
+	alphabet := make([]rune, 0, alphabetSize)
+	for i := uint64(0); i < alphabetSize; i++{
+		ch, err := binary.ReadUvarint(r)
+		if err != nil{
+			return Dictionary{}, fmt.Errorf("%w: %w", ErrInvalidData, err)
+		}
+		if ch > utf8.MaxRune{
+			return Dictionary{}, fmt.Errorf("%w: invalid symbol %d", ErrInvalidData, ch)
+		}
+		alphabet = append(alphabet, rune(ch))
+	}
+
+	shape, err := readBytes(r)
+	if err != nil{
+		return Dictionary{}, err
+	}
+	br := intcode.NewBitReader(shape)
+
+	root := &node{}
+	wordsCount := 0
+	wordsLength := 0
+	internalCount := 0
+
+	var build func(n *node) error
+	build = func(n *node) error{
+		// a complete tree of internalCount+1 internal nodes has this many words and nodes
+		words := (internalCount + 1) * (len(alphabet) - 1) + 1
+		nodes := int64(internalCount + 1) * int64(len(alphabet)) + 1
+		if err := limits.CheckSymbols(words); err != nil{
+			return err
+		}
+		if err := limits.CheckMemory(nodes * nodeSize); err != nil{
+			return err
+		}
+
+		n.children = make([]*node, len(alphabet))
+
+		for i, ch := range alphabet{
+			child := n.newChild(ch)
+			n.children[i] = child
+
+			bit, err := br.ReadBit()
+			if err != nil{
+				return fmt.Errorf("%w: %w", ErrInvalidData, err)
+			}
+
+			if bit == 0{
+				wordsCount++
+				if wordsCount > maxWords{
+					return fmt.Errorf("%w: too many words for %d bit codes", ErrInvalidData, codewordSize)
+				}
+				wordsLength += child.depth
+				if wordsLength > MaxWordsLength{
+					return fmt.Errorf("%w: words are longer than %d symbols", ErrInvalidData, MaxWordsLength)
+				}
+				continue
+			}
+
+			// a complete tree has fewer internal nodes than leaves
+			internalCount++
+			if internalCount >= maxWords{
+				return fmt.Errorf("%w: too many words for %d bit codes", ErrInvalidData, codewordSize)
+			}
+
+			if err := build(child); err != nil{
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if len(alphabet) > 0{
+		if err := build(root); err != nil{
+			return Dictionary{}, err
+		}
+	}
+
+	return newDictionary(int(codewordSize), alphabet, root), nil
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error){
+	size, err := binary.ReadUvarint(r)
+	if err != nil{
+		return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+	}
+	if size > uint64(r.Len()){
+		return nil, fmt.Errorf("%w: %d bytes announced, %d left", ErrInvalidData, size, r.Len())
+	}
+
+	res := make([]byte, size)
+	_, _ = r.Read(res)
+
+	return res, nil
+}
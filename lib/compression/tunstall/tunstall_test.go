@@ -0,0 +1,149 @@
+package tunstall
+
+import (
+	"testing"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/intcode"
+)
+
+func Test_RoundTrip(t* testing.T){
+	tests := []struct{
+		name string
+		codewordSize int
+		str string
+	}{
+		{name: "empty", codewordSize: 4, str: ""},
+		{name: "single symbol", codewordSize: 4, str: "aaaaaaa"},
+		{name: "with tail", codewordSize: 3, str: "aaaaaaabbca"},
+		{name: "base test", codewordSize: 8, str: "My name is Ted"},
+		{name: "unicode", codewordSize: 6, str: "世界世界和平! 世界"},
+		{name: "long text", codewordSize: 12, str: strings.Repeat("abracadabra, ", 200)},
+		{name: "alphabet fills dictionary", codewordSize: 2, str: "abcd"},
+		// words grow up to MaxWordsLength
+		{name: "skewed text", codewordSize: 16, str: strings.Repeat("a", 100000) + "b"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			g, err := NewGenerator(tt.codewordSize)
+			if err != nil{
+				t.Fatalf("NewGenerator() error = %v", err)
+			}
+			ed := New(g)
+
+			encoded, err := ed.Encode(tt.str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			got, err := ed.Decode(encoded)
+			if err != nil || got != tt.str{
+				t.Errorf("Decode() = #%v %v#, want #%v#", got, err, tt.str)
+			}
+		})
+	}
+}
+
+func Test_encodeWords(t* testing.T){
+	g, _ := NewGenerator(3)
+	dict, _ := g.NewDictionary("aaaaaaabbc")
+
+	// aaa | ab | c | aa
+	codes, tail := encodeWords(dict, "aaaabcaa")
+
+	if want := []uint32{0, 3, 6}; len(codes) != len(want) || codes[0] != want[0] || codes[1] != want[1] || codes[2] != want[2]{
+		t.Errorf("encodeWords() codes = #%v#, want #%v#", codes, want)
+	}
+	if tail != "aa"{
+		t.Errorf("encodeWords() tail = #%v#, want #aa#", tail)
+	}
+}
+
+func Test_Decode_Errors(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "codeword size", data: []byte{0}},
+		{name: "truncated alphabet", data: []byte{2, 3, 'a'}},
+		{name: "truncated shape", data: []byte{2, 2, 'a', 'b', 5}},
+		// 2 symbols, 2 bit codes, 4 internal nodes in a row
+		{name: "too many words", data: []byte{2, 2, 'a', 'b', 1, 0xFF}},
+		// a, b; one code 3 while only 2 words
+		{name: "unknown code", data: []byte{2, 2, 'a', 'b', 1, 0, 0, 1, 0xC0}},
+		// 15 KB header of words about 60000 symbols long
+		{name: "deep dictionary", data: deepDictionary(60000)},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			g, _ := NewGenerator(2)
+
+			if _, err := New(g).Decode(tt.data); !errors.Is(err, ErrInvalidData){
+				t.Errorf("Decode() error = #%v#, want #%v#", err, ErrInvalidData)
+			}
+		})
+	}
+}
+
+func Test_Decode_Limits(t* testing.T){
+	g, _ := NewGenerator(8)
+	str := strings.Repeat("abracadabra, ", 20)
+
+	encoded, err := New(g).Encode(str)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct{
+		name string
+		data []byte
+		limits compression.Limits
+		wantErr error
+	}{
+		{name: "within limits", data: encoded, limits: compression.Limits{MaxTableSymbols: 256, MaxMemory: 1 << 20}},
+		{name: "words", data: encoded, limits: compression.Limits{MaxTableSymbols: 100}, wantErr: compression.ErrTooManySymbols},
+		{name: "alphabet", data: encoded, limits: compression.Limits{MaxTableSymbols: 5}, wantErr: compression.ErrTooManySymbols},
+		{name: "memory", data: encoded, limits: compression.Limits{MaxMemory: 4000}, wantErr: compression.ErrMemoryLimit},
+		// words are counted before the chain is built
+		{name: "deep dictionary", data: deepDictionary(60000), limits: compression.Limits{MaxTableSymbols: 1000}, wantErr: compression.ErrTooManySymbols},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, err := New(g).WithLimits(tt.limits).Decode(tt.data)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("Decode() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if err == nil && got != str{
+				t.Errorf("Decode() = #%v#, want #%v#", got, str)
+			}
+		})
+	}
+}
+
+// deepDictionary returns a dictionary of 20 bit codes and 2 symbols
+// which internal nodes are a chain of depth nodes
+func deepDictionary(depth int) []byte{
+	w := intcode.NewBitWriter()
+	for i := 0; i < depth; i++{
+		w.WriteBit(1)
+	}
+	for i := 0; i < depth+2; i++{
+		w.WriteBit(0)
+	}
+
+	data := binary.AppendUvarint([]byte{20, 2, 'a', 'b'}, uint64(len(w.Bytes())))
+
+	return append(data, w.Bytes()...)
+}
+
+func Test_Encode_InvalidText(t* testing.T){
+	g, _ := NewGenerator(4)
+
+	if _, err := New(g).Encode("ab\xb3"); !errors.Is(err, ErrInvalidText){
+		t.Errorf("Encode() error = #%v#, want #%v#", err, ErrInvalidText)
+	}
+}
@@ -0,0 +1,223 @@
+package vlc
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"archiver/lib/compression"
+)
+
+// DefaultBlockSize is the size of input blocks encoded with their own tables
+const DefaultBlockSize = 1 << 20
+
+// blockHeaderSize is raw size and encoded size of a block
+const blockHeaderSize = 8
+
+
+// WithThreads returns EncoderDecoder which encodes and decodes
+// up to threads blocks concurrently, 0 means the number of CPUs
+func (ed EncoderDecoder) WithThreads(threads int) EncoderDecoder{
+	if threads <= 0{
+		threads = runtime.NumCPU()
+	}
+	ed.threads = threads
+
+	return ed
+}
+
+// WithBlockSize returns EncoderDecoder which splits input into blocks of blockSize bytes
+func (ed EncoderDecoder) WithBlockSize(blockSize int) EncoderDecoder{
+	if blockSize <= 0{
+		blockSize = DefaultBlockSize
+	}
+	ed.blockSize = blockSize
+
+	return ed
+}
+// WithLimits returns EncoderDecoder which checks limits before and while decoding
+func (ed EncoderDecoder) WithLimits(limits compression.Limits) EncoderDecoder{
+	ed.limits = limits
+
+	return ed
+}
+
+
+// Encode splits str into blocks and encodes them concurrently.
+// Encoded file is a sequence of blocks: raw size | encoded size | encoded block,
+// terminated by an empty block and followed by the block index.
+// The output doesn't depend on the number of threads.
+// Codes are built for characters, so binary data isn't encoded.
+func (ed EncoderDecoder) Encode(str string) ([]byte, error){
+	if !utf8.ValidString(str){
+		return nil, ErrInvalidText
+	}
+
+	blocks := splitBlocks(str, ed.blockSize)
+	encoded := make([][]byte, len(blocks))
+
+	err := parallel(len(blocks), ed.threads, func(i int) error{
+		var err error
+		encoded[i], err = ed.encodeBlock(blocks[i])
+
+		return err
+	})
+	if err != nil{
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	index := make([]blockInfo, 0, len(blocks))
+	rawOffset := int64(0)
+
+	for i, block := range encoded{
+		buf.Write(encodeBlockHeader(len(blocks[i]), len(block)))
+
+		index = append(index, blockInfo{
+			rawOffset: rawOffset,
+			rawSize: len(blocks[i]),
+			offset: int64(buf.Len()),
+			size: len(block),
+		})
+
+		buf.Write(block)
+		rawOffset += int64(len(blocks[i]))
+	}
+
+	buf.Write(encodeBlockHeader(0, 0))
+	buf.Write(encodeIndex(index))
+
+	return buf.Bytes(), nil
+}
+
+// Decode decodes blocks concurrently using the block index
+func (ed EncoderDecoder) Decode(data []byte) (string, error){
+	blocks, err := readIndex(bytes.NewReader(data), int64(len(data)))
+	if err != nil{
+		return "", err
+	}
+
+	if err := ed.checkLimits(blocks, int64(len(data))); err != nil{
+		return "", err
+	}
+
+	decoded := make([]string, len(blocks))
+
+	err = parallel(len(blocks), ed.threads, func(i int) error{
+		b := blocks[i]
+
+		var err error
+		decoded[i], err = ed.decodeBlock(data[b.offset:b.offset+int64(b.size)])
+		if err != nil{
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		if len(decoded[i]) != b.rawSize{
+			return fmt.Errorf("%w: block %d is %d bytes, want %d", ErrInvalidBlocks, i, len(decoded[i]), b.rawSize)
+		}
+
+		return nil
+	})
+	if err != nil{
+		return "", err
+	}
+
+	return strings.Join(decoded, ""), nil
+}
+
+// checkLimits checks sizes declared by the block index before decoding.
+// Decoding holds decoded blocks and their join, and every block decoded
+// concurrently grows its output up to twice its raw size.
+// Codes are read from encoded bytes, so their size isn't multiplied.
+func (ed EncoderDecoder) checkLimits(blocks []blockInfo, size int64) error{
+	total := int64(0)
+	maxRaw := 0
+	for _, b := range blocks{
+		total += int64(b.rawSize)
+		maxRaw = max(maxRaw, b.rawSize)
+	}
+
+	if err := ed.limits.CheckOutput(size, total); err != nil{
+		return err
+	}
+
+	concurrent := int64(min(max(ed.threads, 1), len(blocks)))
+
+	return ed.limits.CheckMemory(2*total + concurrent*2*int64(maxRaw))
+}
+
+
+// splitBlocks splits str into blocks of blockSize bytes,
+// blocks are cut at character boundaries
+func splitBlocks(str string, blockSize int) []string{
+	var res []string
+
+	for len(str) > 0{
+		end := min(blockSize, len(str))
+
+		if end < len(str){
+			end = runeBoundary(str, end)
+		}
+
+		res = append(res, str[:end])
+		str = str[end:]
+	}
+
+	return res
+}
+
+// runeBoundary returns the nearest character start before pos,
+// or after pos if the character at pos starts at 0.
+// Invalid sequences are cut anywhere.
+func runeBoundary(str string, pos int) int{
+	for i := pos; i > 0 && pos-i < utf8.UTFMax; i--{
+		if utf8.RuneStart(str[i]){
+			return i
+		}
+	}
+
+	for i := pos; i <= len(str) && i-pos < utf8.UTFMax; i++{
+		if i == len(str) || utf8.RuneStart(str[i]){
+			return i
+		}
+	}
+
+	return pos
+}
+
+func encodeBlockHeader(rawSize, size int) []byte{
+	return append(encodeInt(rawSize), encodeInt(size)...)
+}
+
+// parallel calls f for 0..n-1 using up to threads goroutines,
+// returns the error of the first failed call
+func parallel(n int, threads int, f func(i int) error) error{
+	errs := make([]error, n)
+	sem := make(chan struct{}, max(threads, 1))
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++{
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(i int){
+			defer wg.Done()
+			defer func(){ <-sem }()
+
+			errs[i] = f(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs{
+		if err != nil{
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package vlc
+
+import (
+	"testing"
+	"reflect"
+	"bytes"
+	"errors"
+	"strings"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc/table/haffman"
+)
+
+func Test_splitBlocks(t* testing.T){
+	tests := []struct{
+		name string
+		str string
+		blockSize int
+		want []string
+	}{
+		{
+			name: "empty",
+			str: "",
+			blockSize: 4,
+			want: nil,
+		},
+		{
+			name: "base test",
+			str: "abcdefghij",
+			blockSize: 4,
+			want: []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name: "character on the boundary",
+			str: "abcЖdef",
+			blockSize: 4,
+			want: []string{"abc", "Жde", "f"},
+		},
+		{
+			name: "character longer than block",
+			str: "世界",
+			blockSize: 2,
+			want: []string{"世", "界"},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got := splitBlocks(tt.str, tt.blockSize)
+			if !reflect.DeepEqual(got, tt.want){
+				t.Errorf("splitBlocks() = #%q#, want #%q#", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode_Threads(t* testing.T){
+	str := strings.Repeat("My name is Ted. Меня зовут Тед. ", 500)
+
+	var want []byte
+	for _, threads := range []int{1, 2, 3, 8}{
+		ed := New(haffman.NewGenerator()).WithBlockSize(100).WithThreads(threads)
+
+		got, err := ed.Encode(str)
+		if err != nil{
+			t.Fatalf("Encode() error = %v", err)
+		}
+
+		if want == nil{
+			want = got
+		}
+		if !bytes.Equal(got, want){
+			t.Errorf("Encode() with %d threads differs from 1 thread", threads)
+		}
+
+		decoded, err := ed.Decode(got)
+		if err != nil || decoded != str{
+			t.Errorf("Decode() with %d threads = #%.50v %v#, want #%.50v#", threads, decoded, err, str)
+		}
+	}
+}
+
+func TestDecode_Limits(t* testing.T){
+	str := strings.Repeat("aaaaaaab", 1000)
+
+	encoded, err := New(haffman.NewGenerator()).WithBlockSize(1000).Encode(str)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct{
+		name string
+		limits compression.Limits
+		wantErr error
+	}{
+		{name: "no limits", limits: compression.Limits{}},
+		{name: "within limits", limits: compression.Limits{MaxTableSymbols: 2, MaxOutputSize: 8000, MaxRatio: 10, MaxMemory: 1 << 20}},
+		{name: "output size", limits: compression.Limits{MaxOutputSize: 7999}, wantErr: compression.ErrOutputTooLarge},
+		{name: "ratio", limits: compression.Limits{MaxRatio: 2}, wantErr: compression.ErrRatioTooLarge},
+		{name: "memory", limits: compression.Limits{MaxMemory: 16000}, wantErr: compression.ErrMemoryLimit},
+		{name: "table symbols", limits: compression.Limits{MaxTableSymbols: 1}, wantErr: compression.ErrTooManySymbols},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, err := New(haffman.NewGenerator()).WithLimits(tt.limits).Decode(encoded)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("Decode() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+
+			if err == nil && got != str{
+				t.Errorf("Decode() = #%q#, want #%q#", got, str)
+			}
+		})
+	}
+}
+
+func Test_parseFile_Invalid(t* testing.T){
+	block, err := New(haffman.NewGenerator()).encodeBlock("abracadabra")
+	if err != nil{
+		t.Fatalf("encodeBlock() error = %v", err)
+	}
+
+	modify := func(f func(b []byte) []byte) []byte{
+		return f(append([]byte(nil), block...))
+	}
+
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "truncated header", data: block[:5]},
+		{name: "unknown kind", data: modify(func(b []byte) []byte{ b[0] = 7; return b })},
+		{name: "table size", data: modify(func(b []byte) []byte{ b[1] = 0xff; return b })},
+		{name: "data size", data: modify(func(b []byte) []byte{ b[5] = 0xff; return b })},
+		{name: "truncated data", data: block[:len(block)-1]},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, _, _, err := parseFile(tt.data, nil, compression.Limits{}); !errors.Is(err, ErrInvalidBlock){
+				t.Errorf("parseFile() error = #%v#, want #%v#", err, ErrInvalidBlock)
+			}
+		})
+	}
+}
+
+func TestEncode_InvalidText(t* testing.T){
+	if _, err := New(haffman.NewGenerator()).Encode("ab\xb3"); !errors.Is(err, ErrInvalidText){
+		t.Errorf("Encode() error = #%v#, want #%v#", err, ErrInvalidText)
+	}
+}
@@ -0,0 +1,123 @@
+package vlc
+
+import (
+	"testing"
+	"bytes"
+	"io"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc/table"
+	"archiver/lib/compression/vlc/table/alphabetic"
+	"archiver/lib/compression/vlc/table/haffman"
+	"archiver/lib/compression/vlc/table/shanon_fano"
+)
+
+var fuzzSeeds = []string{
+	"",
+	"a",
+	"abracadabra",
+	"My name is Ted. Меня зовут Тед.",
+	"世界 世界 世界",
+}
+
+// fuzzCodecs returns vlc codecs with every generator and a pretrained table,
+// small blocks make seeds span several of them
+func fuzzCodecs(f *testing.F) []EncoderDecoder{
+	stored, err := table.NewStored(table.Train(haffman.NewGenerator(), table.NewHistogram("abracadabra", 1)))
+	if err != nil{
+		f.Fatalf("NewStored() error = %v", err)
+	}
+
+	return []EncoderDecoder{
+		New(shanon_fano.NewGenerator()).WithBlockSize(8),
+		New(haffman.NewGenerator()).WithBlockSize(8),
+		New(alphabetic.NewGenerator()).WithBlockSize(8),
+		NewStatic(stored).WithBlockSize(8),
+	}
+}
+
+// FuzzParseFile checks that invalid blocks are rejected without panics
+func FuzzParseFile(f *testing.F){
+	codecs := fuzzCodecs(f)
+
+	for _, ed := range codecs{
+		for _, seed := range fuzzSeeds{
+			if block, err := ed.encodeBlock(seed); err == nil{
+				f.Add(block)
+			}
+		}
+	}
+
+	stored := codecs[len(codecs)-1].stored
+
+	f.Fuzz(func(t* testing.T, data []byte){
+		for _, s := range []*table.Stored{nil, stored}{
+			tbl, codes, bits, err := parseFile(data, s, compression.Limits{})
+			if err != nil{
+				continue
+			}
+
+			// only the absence of panics is checked
+			_, _ = tbl.DecodeBits(codes, bits)
+		}
+	})
+}
+
+// FuzzDecode checks that decoding arbitrary data returns errors and never panics,
+// both whole files and their sections are decoded
+func FuzzDecode(f *testing.F){
+	codecs := fuzzCodecs(f)
+
+	for i, ed := range codecs{
+		for _, seed := range fuzzSeeds{
+			if encoded, err := ed.Encode(seed); err == nil{
+				f.Add(byte(i), encoded)
+			}
+		}
+	}
+
+	f.Fuzz(func(t* testing.T, codec byte, data []byte){
+		ed := codecs[int(codec)%len(codecs)]
+
+		decoded, err := ed.Decode(data)
+		if err != nil{
+			return
+		}
+
+		sr, err := NewSectionReader(bytes.NewReader(data), int64(len(data)), ed)
+		if err != nil{
+			t.Fatalf("NewSectionReader() error = %v, Decode() succeeded", err)
+		}
+
+		got, err := io.ReadAll(io.NewSectionReader(sr, 0, sr.Size()))
+		if err != nil || string(got) != decoded{
+			t.Errorf("ReadAt() = #%q %v#, want #%q#", got, err, decoded)
+		}
+	})
+}
+
+// FuzzRoundTrip checks that every valid text is decoded back unchanged
+func FuzzRoundTrip(f *testing.F){
+	codecs := fuzzCodecs(f)
+
+	for i := range codecs{
+		for _, seed := range fuzzSeeds{
+			f.Add(byte(i), seed)
+		}
+	}
+
+	f.Fuzz(func(t* testing.T, codec byte, str string){
+		ed := codecs[int(codec)%len(codecs)]
+
+		encoded, err := ed.Encode(str)
+		if err != nil{
+			// invalid UTF-8
+			t.Skip()
+		}
+
+		decoded, err := ed.Decode(encoded)
+		if err != nil || decoded != str{
+			t.Errorf("Decode() = #%q %v#, want #%q#", decoded, err, str)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package vlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const indexMagic = "VIDX"
+
+const (
+	// raw offset | offset of encoded block | raw size | encoded size
+	indexEntrySize = 24
+	// blocks count | magic
+	indexTrailerSize = 8
+)
+
+var ErrInvalidBlocks = errors.New("invalid block index")
+
+// blockInfo maps block of the original data to the encoded block
+type blockInfo struct{
+	rawOffset int64
+	rawSize int
+	offset int64
+	size int
+}
+
+
+func encodeIndex(blocks []blockInfo) []byte{
+	res := make([]byte, 0, len(blocks)*indexEntrySize + indexTrailerSize)
+
+	for _, b := range blocks{
+		res = binary.BigEndian.AppendUint64(res, uint64(b.rawOffset))
+		res = binary.BigEndian.AppendUint64(res, uint64(b.offset))
+		res = binary.BigEndian.AppendUint32(res, uint32(b.rawSize))
+		res = binary.BigEndian.AppendUint32(res, uint32(b.size))
+	}
+
+	res = binary.BigEndian.AppendUint32(res, uint32(len(blocks)))
+
+	return append(res, indexMagic...)
+}
+
+// readIndex reads the block index at the end of encoded file of the given size
+// and checks that blocks follow each other and fill the file,
+// index entries must match the headers of blocks and the empty block must end them
+func readIndex(r io.ReaderAt, size int64) ([]blockInfo, error){
+	if size < blockHeaderSize + indexTrailerSize{
+		return nil, fmt.Errorf("%w: file is too short", ErrInvalidBlocks)
+	}
+
+	trailer := make([]byte, indexTrailerSize)
+	if _, err := r.ReadAt(trailer, size-indexTrailerSize); err != nil{
+		return nil, err
+	}
+	if string(trailer[4:]) != indexMagic{
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidBlocks)
+	}
+
+	count := int64(binary.BigEndian.Uint32(trailer[:4]))
+	indexSize := count * indexEntrySize
+	blocksEnd := size - indexTrailerSize - indexSize - blockHeaderSize
+
+	// every block takes at least its header
+	if blocksEnd < count*blockHeaderSize{
+		return nil, fmt.Errorf("%w: %d blocks don't fit into the file", ErrInvalidBlocks, count)
+	}
+
+	index := make([]byte, indexSize)
+	if _, err := r.ReadAt(index, blocksEnd+blockHeaderSize); err != nil{
+		return nil, err
+	}
+
+	res := make([]blockInfo, 0, count)
+	rawOffset, offset := int64(0), int64(0)
+	header := make([]byte, blockHeaderSize)
+
+	for i := int64(0); i < count; i++{
+		entry := index[i*indexEntrySize:(i+1)*indexEntrySize]
+		b := blockInfo{
+			rawOffset: int64(binary.BigEndian.Uint64(entry[0:8])),
+			offset: int64(binary.BigEndian.Uint64(entry[8:16])),
+			rawSize: int(binary.BigEndian.Uint32(entry[16:20])),
+			size: int(binary.BigEndian.Uint32(entry[20:24])),
+		}
+
+		if b.rawOffset != rawOffset || b.offset != offset+blockHeaderSize || b.offset+int64(b.size) > blocksEnd{
+			return nil, fmt.Errorf("%w: block %d", ErrInvalidBlocks, i)
+		}
+
+		if _, err := r.ReadAt(header, offset); err != nil{
+			return nil, err
+		}
+		if !bytes.Equal(header, encodeBlockHeader(b.rawSize, b.size)){
+			return nil, fmt.Errorf("%w: block %d header doesn't match the index", ErrInvalidBlocks, i)
+		}
+
+		res = append(res, b)
+		rawOffset += int64(b.rawSize)
+		offset = b.offset + int64(b.size)
+	}
+
+	if offset != blocksEnd{
+		return nil, fmt.Errorf("%w: unexpected data after blocks", ErrInvalidBlocks)
+	}
+
+	if _, err := r.ReadAt(header, blocksEnd); err != nil{
+		return nil, err
+	}
+	if !bytes.Equal(header, encodeBlockHeader(0, 0)){
+		return nil, fmt.Errorf("%w: no empty block after blocks", ErrInvalidBlocks)
+	}
+
+	return res, nil
+}
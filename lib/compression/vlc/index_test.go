@@ -0,0 +1,74 @@
+package vlc
+
+import (
+	"testing"
+	"reflect"
+	"bytes"
+	"errors"
+
+	"archiver/lib/compression/vlc/table/haffman"
+)
+
+func Test_readIndex(t* testing.T){
+	ed := New(haffman.NewGenerator()).WithBlockSize(4)
+
+	encoded, err := ed.Encode("abcdefghij")
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	blocks, err := readIndex(bytes.NewReader(encoded), int64(len(encoded)))
+	if err != nil{
+		t.Fatalf("readIndex() error = %v", err)
+	}
+
+	gotOffsets := make([]int64, 0, len(blocks))
+	for _, b := range blocks{
+		gotOffsets = append(gotOffsets, b.rawOffset)
+	}
+	if want := []int64{0, 4, 8}; !reflect.DeepEqual(gotOffsets, want){
+		t.Errorf("readIndex() raw offsets = #%v#, want #%v#", gotOffsets, want)
+	}
+
+	for _, b := range blocks{
+		if _, err := ed.decodeBlock(encoded[b.offset:b.offset+int64(b.size)]); err != nil{
+			t.Errorf("decodeBlock() at %d error = %v", b.offset, err)
+		}
+	}
+
+	tooMany := bytes.Clone(encoded)
+	tooMany[len(tooMany)-len(indexMagic)-1]++
+
+	// the raw size of the second block is 3 in its header
+	badHeader := bytes.Clone(encoded)
+	badHeader[blocks[1].offset-blockHeaderSize+3]--
+
+	// the raw size of the last block is 3 in the index
+	badEntry := bytes.Clone(encoded)
+	badEntry[len(badEntry)-indexTrailerSize-indexEntrySize+19]++
+
+	// the empty block is replaced with a block of the raw size 1
+	noEnd := bytes.Clone(encoded)
+	noEnd[blocks[2].offset+int64(blocks[2].size)+3] = 1
+
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "bad magic", data: encoded[:len(encoded)-1]},
+		{name: "truncated", data: encoded[1:]},
+		{name: "too many blocks", data: tooMany},
+		{name: "leading data", data: append([]byte{0}, encoded...)},
+		{name: "block header mismatch", data: badHeader},
+		{name: "index entry mismatch", data: badEntry},
+		{name: "no empty block", data: noEnd},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := readIndex(bytes.NewReader(tt.data), int64(len(tt.data))); !errors.Is(err, ErrInvalidBlocks){
+				t.Errorf("readIndex() error = #%v#, want #%v#", err, ErrInvalidBlocks)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package vlc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// SectionReader reads ranges of the original data from encoded file,
+// only blocks covering the range are decoded
+type SectionReader struct{
+	r io.ReaderAt
+	decoder EncoderDecoder
+	blocks []blockInfo
+	size int64
+
+	// the last decoded block, sequential reads usually hit it
+	mu sync.Mutex
+	cached int
+	cachedData string
+}
+
+// NewSectionReader reads the block index of encoded file of the given size,
+// decoder is used to decode blocks, e.g. it may hold the pretrained table
+func NewSectionReader(r io.ReaderAt, size int64, decoder EncoderDecoder) (*SectionReader, error){
+	blocks, err := readIndex(r, size)
+	if err != nil{
+		return nil, err
+	}
+
+	sr := &SectionReader{
+		r: r,
+		decoder: decoder,
+		blocks: blocks,
+		cached: -1,
+	}
+
+	if len(blocks) > 0{
+		last := blocks[len(blocks)-1]
+		sr.size = last.rawOffset + int64(last.rawSize)
+	}
+
+	return sr, nil
+}
+
+// Size returns the size of the original data
+func (sr *SectionReader) Size() int64{
+	return sr.size
+}
+
+func (sr *SectionReader) ReadAt(p []byte, off int64) (int, error){
+	if off < 0{
+		return 0, fmt.Errorf("vlc: negative offset %d", off)
+	}
+
+	// the first block ending after off
+	i := sort.Search(len(sr.blocks), func(i int) bool {
+		return sr.blocks[i].rawOffset + int64(sr.blocks[i].rawSize) > off
+	})
+
+	n := 0
+	for ; n < len(p) && i < len(sr.blocks); i++{
+		data, err := sr.block(i)
+		if err != nil{
+			return n, err
+		}
+
+		n += copy(p[n:], data[off+int64(n)-sr.blocks[i].rawOffset:])
+	}
+
+	if n < len(p){
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+func (sr *SectionReader) block(i int) (string, error){
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	if sr.cached == i{
+		return sr.cachedData, nil
+	}
+
+	b := sr.blocks[i]
+
+	encoded := make([]byte, b.size)
+	if _, err := sr.r.ReadAt(encoded, b.offset); err != nil{
+		return "", err
+	}
+
+	data, err := sr.decoder.decodeBlock(encoded)
+	if err != nil{
+		return "", fmt.Errorf("block %d: %w", i, err)
+	}
+	if len(data) != b.rawSize{
+		return "", fmt.Errorf("%w: block %d is %d bytes, want %d", ErrInvalidBlocks, i, len(data), b.rawSize)
+	}
+
+	sr.cached, sr.cachedData = i, data
+
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package vlc
+
+import (
+	"testing"
+	"bytes"
+	"io"
+	"strings"
+
+	"archiver/lib/compression/vlc/table/shanon_fano"
+)
+
+func TestSectionReader(t* testing.T){
+	var lines strings.Builder
+	for i := 0; i < 500; i++{
+		lines.WriteString(strings.Repeat("лог ", i%7) + "log line\n")
+	}
+	str := lines.String()
+
+	encoded, err := New(shanon_fano.NewGenerator()).WithBlockSize(256).Encode(str)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	sr, err := NewSectionReader(bytes.NewReader(encoded), int64(len(encoded)), New(shanon_fano.NewGenerator()))
+	if err != nil{
+		t.Fatalf("NewSectionReader() error = %v", err)
+	}
+
+	if sr.Size() != int64(len(str)){
+		t.Errorf("Size() = #%v#, want #%v#", sr.Size(), len(str))
+	}
+
+	tests := []struct{
+		name string
+		off int64
+		length int
+		wantErr error
+	}{
+		{name: "inside block", off: 10, length: 20},
+		{name: "across blocks", off: 250, length: 600},
+		{name: "whole data", off: 0, length: len(str)},
+		{name: "past the end", off: int64(len(str)) - 5, length: 10, wantErr: io.EOF},
+		{name: "after the end", off: int64(len(str)) + 5, length: 10, wantErr: io.EOF},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			p := make([]byte, tt.length)
+			n, err := sr.ReadAt(p, tt.off)
+
+			want := ""
+			if tt.off < int64(len(str)){
+				want = str[tt.off:min(tt.off+int64(tt.length), int64(len(str)))]
+			}
+
+			if err != tt.wantErr || string(p[:n]) != want{
+				t.Errorf("ReadAt() = #%q %v#, want #%q %v#", p[:n], err, want, tt.wantErr)
+			}
+		})
+	}
+}
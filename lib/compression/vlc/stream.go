@@ -0,0 +1,265 @@
+package vlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Writer encodes data written to it into the same file as Encode does,
+// blocks are written as soon as threads of them are filled
+type Writer struct{
+	w io.Writer
+	encoder EncoderDecoder
+	buf []byte
+	index []blockInfo
+	rawOffset int64
+	// offset is the number of bytes written to w
+	offset int64
+	err error
+	closed bool
+}
+
+// NewWriter returns Writer encoding into w, Close must be called to write the last blocks
+func NewWriter(w io.Writer, encoder EncoderDecoder) *Writer{
+	return &Writer{w: w, encoder: encoder}
+}
+
+func (w *Writer) Write(p []byte) (int, error){
+	if w.err != nil{
+		return 0, w.err
+	}
+
+	w.buf = append(w.buf, p...)
+
+	// the end of a block is known when the character after it is buffered
+	if len(w.buf) >= max(w.encoder.threads, 1) * w.encoder.blockSize + utf8.UTFMax{
+		w.err = w.flush(false)
+	}
+	if w.err != nil{
+		return 0, w.err
+	}
+
+	return len(p), nil
+}
+
+// Close writes buffered blocks, the end block and the block index, w isn't closed
+func (w *Writer) Close() error{
+	if w.closed{
+		return w.err
+	}
+	w.closed = true
+
+	if w.err != nil{
+		return w.err
+	}
+
+	if w.err = w.flush(true); w.err != nil{
+		return w.err
+	}
+
+	_, w.err = w.w.Write(append(encodeBlockHeader(0, 0), encodeIndex(w.index)...))
+
+	return w.err
+}
+
+// flush encodes and writes complete blocks of the buffer, or all of it if final is set.
+// Blocks are cut as splitBlocks cuts them.
+func (w *Writer) flush(final bool) error{
+	var blocks []string
+	blockSize := w.encoder.blockSize
+
+	rest := w.buf
+	for !final && len(rest) >= blockSize + utf8.UTFMax{
+		end := runeBoundary(string(rest[:blockSize + utf8.UTFMax]), blockSize)
+
+		blocks = append(blocks, string(rest[:end]))
+		rest = rest[end:]
+	}
+	if final{
+		blocks = append(blocks, splitBlocks(string(rest), blockSize)...)
+		rest = nil
+	}
+	w.buf = append(w.buf[:0], rest...)
+
+	// blocks are cut at character boundaries, so the text is valid if they all are
+	for _, block := range blocks{
+		if !utf8.ValidString(block){
+			return ErrInvalidText
+		}
+	}
+
+	encoded := make([][]byte, len(blocks))
+
+	err := parallel(len(blocks), w.encoder.threads, func(i int) error{
+		var err error
+		encoded[i], err = w.encoder.encodeBlock(blocks[i])
+
+		return err
+	})
+	if err != nil{
+		return err
+	}
+
+	for i, block := range encoded{
+		w.index = append(w.index, blockInfo{
+			rawOffset: w.rawOffset,
+			rawSize: len(blocks[i]),
+			offset: w.offset + blockHeaderSize,
+			size: len(block),
+		})
+
+		if _, err := w.w.Write(append(encodeBlockHeader(len(blocks[i]), len(block)), block...)); err != nil{
+			return err
+		}
+		w.rawOffset += int64(len(blocks[i]))
+		w.offset += int64(blockHeaderSize + len(block))
+	}
+
+	return nil
+}
+
+
+// Reader decodes encoded file read block by block, up to threads blocks
+// are decoded concurrently. Data after the block index is left unread.
+type Reader struct{
+	r io.Reader
+	decoder EncoderDecoder
+	index []blockInfo
+	rawOffset int64
+	// offset is the number of bytes read from r
+	offset int64
+	decoded string
+	end bool
+	err error
+}
+
+// NewReader returns Reader decoding r
+func NewReader(r io.Reader, decoder EncoderDecoder) *Reader{
+	return &Reader{r: r, decoder: decoder}
+}
+
+func (r *Reader) Read(p []byte) (int, error){
+	for len(r.decoded) == 0{
+		if r.err != nil{
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+
+	n := copy(p, r.decoded)
+	r.decoded = r.decoded[n:]
+
+	return n, nil
+}
+
+// next reads and decodes the next blocks, the block index is checked
+// when the end block is read
+func (r *Reader) next() error{
+	if r.end{
+		return io.EOF
+	}
+
+	var blocks [][]byte
+	first := len(r.index)
+	raw, maxRaw, maxBlock := int64(0), 0, 0
+
+	for len(blocks) < max(r.decoder.threads, 1){
+		header := make([]byte, blockHeaderSize)
+		if _, err := io.ReadFull(r.r, header); err != nil{
+			return fmt.Errorf("%w: block %d: %w", ErrInvalidBlocks, len(r.index), unexpectedEOF(err))
+		}
+		r.offset += blockHeaderSize
+
+		rawSize := int(binary.BigEndian.Uint32(header[:4]))
+		size := int(binary.BigEndian.Uint32(header[4:]))
+		if rawSize == 0 && size == 0{
+			r.end = true
+			break
+		}
+
+		raw, maxRaw, maxBlock = raw + int64(rawSize), max(maxRaw, rawSize), max(maxBlock, size)
+		if err := r.decoder.limits.CheckOutput(r.offset + int64(size), r.rawOffset + raw); err != nil{
+			return err
+		}
+		// decoded blocks and their join, and every encoded block with its growing output
+		if err := r.decoder.limits.CheckMemory(2*raw + int64(len(blocks)+1)*(int64(maxBlock) + 2*int64(maxRaw))); err != nil{
+			return err
+		}
+
+		// the block is read as it comes, so a false size doesn't allocate memory
+		block, err := io.ReadAll(io.LimitReader(r.r, int64(size)))
+		if err != nil{
+			return err
+		}
+		if len(block) != size{
+			return fmt.Errorf("%w: block %d: %w", ErrInvalidBlocks, len(r.index), io.ErrUnexpectedEOF)
+		}
+
+		r.index = append(r.index, blockInfo{
+			rawOffset: r.rawOffset + raw - int64(rawSize),
+			rawSize: rawSize,
+			offset: r.offset,
+			size: size,
+		})
+		blocks = append(blocks, block)
+		r.offset += int64(size)
+	}
+
+	decoded := make([]string, len(blocks))
+
+	err := parallel(len(blocks), r.decoder.threads, func(i int) error{
+		b := r.index[first+i]
+
+		var err error
+		decoded[i], err = r.decoder.decodeBlock(blocks[i])
+		if err != nil{
+			return fmt.Errorf("block %d: %w", first+i, err)
+		}
+		if len(decoded[i]) != b.rawSize{
+			return fmt.Errorf("%w: block %d is %d bytes, want %d", ErrInvalidBlocks, first+i, len(decoded[i]), b.rawSize)
+		}
+
+		return nil
+	})
+	if err != nil{
+		return err
+	}
+
+	r.decoded = strings.Join(decoded, "")
+	r.rawOffset += raw
+
+	if r.end{
+		return r.checkIndex()
+	}
+
+	return nil
+}
+
+// checkIndex reads the block index and checks it describes the read blocks
+func (r *Reader) checkIndex() error{
+	want := encodeIndex(r.index)
+
+	index := make([]byte, len(want))
+	if _, err := io.ReadFull(r.r, index); err != nil{
+		return fmt.Errorf("%w: index: %w", ErrInvalidBlocks, unexpectedEOF(err))
+	}
+
+	if !bytes.Equal(index, want){
+		return fmt.Errorf("%w: index doesn't match blocks", ErrInvalidBlocks)
+	}
+
+	return nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for io.EOF, the file must not end before the index
+func unexpectedEOF(err error) error{
+	if err == io.EOF{
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package vlc
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc/table/haffman"
+)
+
+func TestWriter(t* testing.T){
+	str := strings.Repeat("My name is Ted. Меня зовут Тед. ", 300)
+
+	tests := []struct{
+		name string
+		str string
+		blockSize int
+		threads int
+		// size of writes
+		chunk int
+	}{
+		{name: "one block", str: "My name is Ted", blockSize: 100, threads: 1, chunk: 3},
+		{name: "empty", str: "", blockSize: 100, threads: 1, chunk: 1},
+		{name: "blocks", str: str, blockSize: 100, threads: 1, chunk: 7},
+		{name: "threads", str: str, blockSize: 100, threads: 3, chunk: 1000},
+		{name: "single write", str: str, blockSize: 64, threads: 2, chunk: len(str)},
+		{name: "byte writes", str: str[:2000], blockSize: 33, threads: 4, chunk: 1},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			ed := New(haffman.NewGenerator()).WithBlockSize(tt.blockSize).WithThreads(tt.threads)
+
+			want, err := ed.Encode(tt.str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			w := NewWriter(&buf, ed)
+			for i := 0; i < len(tt.str); i += tt.chunk{
+				if _, err := w.Write([]byte(tt.str[i:min(i+tt.chunk, len(tt.str))])); err != nil{
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil{
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), want){
+				t.Fatalf("Writer output differs from Encode()")
+			}
+
+			// data after the index is left unread
+			r := bytes.NewReader(append(buf.Bytes(), "trailer"...))
+			got, err := io.ReadAll(NewReader(r, ed))
+			if err != nil || string(got) != tt.str{
+				t.Errorf("Reader = #%.50q %v#, want #%.50q#", got, err, tt.str)
+			}
+			if rest, _ := io.ReadAll(r); string(rest) != "trailer"{
+				t.Errorf("Reader left #%q#, want #%q#", rest, "trailer")
+			}
+		})
+	}
+}
+
+func TestWriter_InvalidText(t* testing.T){
+	w := NewWriter(io.Discard, New(haffman.NewGenerator()))
+	if _, err := w.Write([]byte("text \xff")); err != nil{
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := w.Close(); !errors.Is(err, ErrInvalidText){
+		t.Errorf("Close() error = #%v#, want #%v#", err, ErrInvalidText)
+	}
+}
+
+func TestReader_Invalid(t* testing.T){
+	str := strings.Repeat("aaaaaaab", 1000)
+
+	encoded, err := New(haffman.NewGenerator()).WithBlockSize(1000).Encode(str)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	badIndex := append([]byte(nil), encoded...)
+	badIndex[len(badIndex)-indexTrailerSize-1] ^= 1
+
+	// the first block claims 4 GB
+	hugeBlock := append([]byte(nil), encoded...)
+	copy(hugeBlock[4:8], []byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	tests := []struct{
+		name string
+		data []byte
+		limits compression.Limits
+		wantErr error
+	}{
+		{name: "truncated block", data: encoded[:100], wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated index", data: encoded[:len(encoded)-1], wantErr: io.ErrUnexpectedEOF},
+		{name: "index mismatch", data: badIndex, wantErr: ErrInvalidBlocks},
+		{name: "huge block", data: hugeBlock, wantErr: ErrInvalidBlocks},
+		{name: "output size", data: encoded, limits: compression.Limits{MaxOutputSize: 7999}, wantErr: compression.ErrOutputTooLarge},
+		{name: "memory", data: encoded, limits: compression.Limits{MaxMemory: 1000}, wantErr: compression.ErrMemoryLimit},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			r := NewReader(bytes.NewReader(tt.data), New(haffman.NewGenerator()).WithLimits(tt.limits))
+			if _, err := io.ReadAll(r); !errors.Is(err, tt.wantErr){
+				t.Errorf("Reader error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
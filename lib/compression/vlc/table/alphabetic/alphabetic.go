@@ -0,0 +1,169 @@
+package alphabetic
+
+import (
+	"sort"
+	"strings"
+
+	"archiver/lib/compression/vlc/table"
+)
+
+// Generator builds optimal alphabetic codes:
+// codes of characters are ordered the same way as characters,
+// so encoded strings keep their lexicographic order.
+// Code lengths are found with Garsia-Wachs algorithm,
+// which gives the same lengths as Hu-Tucker algorithm.
+type Generator struct{}
+
+type node struct{
+	weight int
+	// index of the character for leaves, -1 for internal nodes
+	char int
+	left *node
+	right *node
+}
+
+func NewGenerator() Generator{
+	return Generator{}
+}
+
+
+func (g Generator) NewTable(text string) table.EncodingTable{
+	return g.NewTableFromHistogram(table.NewHistogram(text, 0))
+}
+
+func (g Generator) NewTableFromHistogram(stat table.Histogram) table.EncodingTable{
+
+	chars := make([]rune, 0, len(stat))
+	for ch := range stat{
+		chars = append(chars, ch)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return chars[i] < chars[j]
+	})
+
+	weights := make([]int, len(chars))
+	for i, ch := range chars{
+		weights[i] = stat[ch]
+	}
+
+	codes := assignCodes(codeLengths(weights))
+
+	res := make(table.EncodingTable, len(chars))
+	for i, ch := range chars{
+		res[ch] = codes[i]
+	}
+
+	return res
+}
+
+
+// codeLengths returns depths of leaves in the optimal alphabetic tree
+func codeLengths(weights []int) []int{
+	res := make([]int, len(weights))
+
+	switch len(weights){
+		case 0:
+			return res
+		case 1:
+			res[0] = 1
+			return res
+	}
+
+	nodes := make([]*node, len(weights))
+	for i, w := range weights{
+		nodes[i] = &node{weight: w, char: i}
+	}
+
+	for len(nodes) > 1{
+		nodes = combine(nodes)
+	}
+
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int){
+		if n.char >= 0{
+			res[n.char] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(nodes[0], 0)
+
+	return res
+}
+
+// combine makes one step of Garsia-Wachs algorithm:
+// finds the leftmost pair nodes[k-1], nodes[k] with weight(k-1) <= weight(k+1),
+// joins it and moves the new node left right after the nearest node
+// which is not lighter than it.
+func combine(nodes []*node) []*node{
+	k := len(nodes) - 1
+	for i := 1; i < len(nodes)-1; i++{
+		if nodes[i-1].weight <= nodes[i+1].weight{
+			k = i
+			break
+		}
+	}
+
+	parent := &node{
+		weight: nodes[k-1].weight + nodes[k].weight,
+		char: -1,
+		left: nodes[k-1],
+		right: nodes[k],
+	}
+
+	nodes = append(nodes[:k-1], nodes[k+1:]...)
+
+	j := k - 1
+	for j > 0 && nodes[j-1].weight < parent.weight{
+		j--
+	}
+
+	nodes = append(nodes, nil)
+	copy(nodes[j+1:], nodes[j:])
+	nodes[j] = parent
+
+	return nodes
+}
+
+// assignCodes builds alphabetic codes with given lengths:
+// every code is the next binary number after the previous one,
+// extended with zeros or shortened to the required length
+func assignCodes(lengths []int) []string{
+	res := make([]string, len(lengths))
+	if len(lengths) == 0{
+		return res
+	}
+
+	code := []byte(strings.Repeat("0", lengths[0]))
+	res[0] = string(code)
+
+	for i := 1; i < len(lengths); i++{
+		code = increment(code)
+
+		if lengths[i] > len(code){
+			code = append(code, strings.Repeat("0", lengths[i]-len(code))...)
+		}else{
+			code = code[:lengths[i]]
+		}
+
+		res[i] = string(code)
+	}
+
+	return res
+}
+
+// increment adds 1 to binary number written with '0' and '1'
+func increment(code []byte) []byte{
+	res := append([]byte{}, code...)
+
+	for i := len(res) - 1; i >= 0; i--{
+		if res[i] == '0'{
+			res[i] = '1'
+			return res
+		}
+		res[i] = '0'
+	}
+
+	return append([]byte{'1'}, res...)
+}
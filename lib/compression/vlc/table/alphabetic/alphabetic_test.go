@@ -0,0 +1,175 @@
+package alphabetic
+
+import (
+	"testing"
+	"reflect"
+	"sort"
+	"math/rand"
+	"strings"
+
+	"archiver/lib/compression/vlc/table"
+)
+
+func Test_NewTable(t* testing.T){
+	tests := []struct{
+		name string
+		text string
+		want table.EncodingTable
+	}{
+		{
+			name: "empty string",
+			text: "",
+			want: table.EncodingTable{},
+		},
+		{
+			name: "single character",
+			text: "aaa",
+			want: table.EncodingTable{'a': "0"},
+		},
+		{
+			name: "base test",
+			text: "abbbcc",
+			want: table.EncodingTable{
+				'a': "00",
+				'b': "01",
+				'c': "1",
+			},
+		},
+		{
+			name: "frequent character is not first",
+			text: "abbbbbbbcd",
+			want: table.EncodingTable{
+				'a': "00",
+				'b': "01",
+				'c': "10",
+				'd': "11",
+			},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if got := NewGenerator().NewTable(tt.text); !reflect.DeepEqual(got, tt.want){
+				t.Errorf("NewTable() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NewTable_OrderPreserving(t* testing.T){
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++{
+		text := randomText(r)
+		tbl := NewGenerator().NewTable(text)
+
+		chars := make([]rune, 0, len(tbl))
+		for ch := range tbl{
+			chars = append(chars, ch)
+		}
+		sort.Slice(chars, func(i, j int) bool {
+			return chars[i] < chars[j]
+		})
+
+		for i := 1; i < len(chars); i++{
+			prev, cur := tbl[chars[i-1]], tbl[chars[i]]
+
+			if prev >= cur || strings.HasPrefix(cur, prev){
+				t.Fatalf("codes of %q and %q are not ordered prefix codes: %v, %v", chars[i-1], chars[i], prev, cur)
+			}
+		}
+	}
+}
+
+func Test_codeLengths_Optimal(t* testing.T){
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 300; n++{
+		weights := make([]int, r.Intn(12) + 1)
+		for i := range weights{
+			weights[i] = r.Intn(50) + 1
+		}
+
+		got := cost(weights, codeLengths(weights))
+		want := optimalCost(weights)
+		if len(weights) == 1{
+			want = weights[0]
+		}
+
+		if got != want{
+			t.Fatalf("codeLengths(%v) cost = #%v#, want #%v#", weights, got, want)
+		}
+	}
+}
+
+func Test_assignCodes(t* testing.T){
+	tests := []struct{
+		name string
+		lengths []int
+		want []string
+	}{
+		{
+			name: "balanced",
+			lengths: []int{2, 2, 2, 2},
+			want: []string{"00", "01", "10", "11"},
+		},
+		{
+			name: "deeper in the middle",
+			lengths: []int{2, 3, 3, 1},
+			want: []string{"00", "010", "011", "1"},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if got := assignCodes(tt.lengths); !reflect.DeepEqual(got, tt.want){
+				t.Errorf("assignCodes() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func cost(weights []int, lengths []int) int{
+	res := 0
+	for i := range weights{
+		res += weights[i] * lengths[i]
+	}
+
+	return res
+}
+
+// optimalCost finds the cost of the optimal alphabetic tree by brute force
+func optimalCost(weights []int) int{
+	n := len(weights)
+	dp := make([][]int, n)
+	sum := make([]int, n+1)
+
+	for i := range weights{
+		dp[i] = make([]int, n)
+		sum[i+1] = sum[i] + weights[i]
+	}
+
+	for size := 2; size <= n; size++{
+		for i := 0; i+size <= n; i++{
+			j := i + size - 1
+			dp[i][j] = -1
+
+			for k := i; k < j; k++{
+				c := dp[i][k] + dp[k+1][j] + sum[j+1] - sum[i]
+				if dp[i][j] < 0 || c < dp[i][j]{
+					dp[i][j] = c
+				}
+			}
+		}
+	}
+
+	return dp[0][n-1]
+}
+
+func randomText(r *rand.Rand) string{
+	var buf strings.Builder
+
+	for i := r.Intn(200); i > 0; i--{
+		buf.WriteRune(rune('a' + r.Intn(r.Intn(26) + 1)))
+	}
+
+	return buf.String()
+}
@@ -2,6 +2,7 @@ package haffman
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"container/heap"
@@ -74,13 +75,18 @@ func NewGenerator() Generator{
 
 func (g Generator) NewTable(text string) table.EncodingTable{
 			
-		encTable := build(text)	
-		return table.EncodingTable(encTable.Export())
+		return g.NewTableFromHistogram(table.NewHistogram(text, 0))
 }
 
+func (g Generator) NewTableFromHistogram(hist table.Histogram) table.EncodingTable{
 
-func (et encodingTable) Export() map[rune]string{
-	res := make(map[rune]string)
+		encTable := buildFromStat(charStat(hist))
+		return encTable.Export()
+}
+
+
+func (et encodingTable) Export() table.EncodingTable{
+	res := make(table.EncodingTable)
 
 	for k, v := range et{
 		byteStr := fmt.Sprintf("%b", v.Bits)
@@ -98,15 +104,27 @@ func (et encodingTable) Export() map[rune]string{
 
 
 func build(str string) encodingTable{
+	return buildFromStat(newCharStat(str))
+}
 
-	stat := newCharStat(str)
+func buildFromStat(stat charStat) encodingTable{
 	queue := &Queue{}
 	heap.Init(queue)
 
-	for ch, qty := range stat{
+	// equal internal nodes are ordered by insertion,
+	// so leaves are pushed in the same order every time
+	chars := make([]rune, 0, len(stat))
+	for ch := range stat{
+		chars = append(chars, ch)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return chars[i] < chars[j]
+	})
+
+	for _, ch := range chars{
 		item := &Node{
 			Char: ch,
-			Quantite: qty,
+			Quantite: stat[ch],
 		}
 		heap.Push(queue, item)
 	}
@@ -197,11 +215,5 @@ func assignCodes(node *Node, table encodingTable) {
 
 func newCharStat(text string) charStat{
 
-	res := make(charStat)
-
-	for _, ch := range text{
-		res[ch]++
-	}
-	
-	return res
+	return charStat(table.NewHistogram(text, 0))
 }
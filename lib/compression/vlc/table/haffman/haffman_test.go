@@ -42,19 +42,19 @@ func TestNewGenerator_NewTable(t *testing.T) {
 			name:  "three characters with equal frequency",
 			input: "abcabcabc",
 			want: table.EncodingTable{
-				'a': "00",
-				'b': "01",
-				'c': "1",
+				'a': "10",
+				'b': "11",
+				'c': "0",
 			},
 		},
 		{
 			name:  "different frequencies",
 			input: "aaabbbccd",
 			want: table.EncodingTable{
-				'a': "1",
-				'b': "01",
-				'c': "001",
-				'd': "000",
+				'a': "11",
+				'b': "0",
+				'c': "101",
+				'd': "100",
 			},
 		},
 		{
@@ -63,9 +63,9 @@ func TestNewGenerator_NewTable(t *testing.T) {
 			want: table.EncodingTable{
 				'世': "10",
 				'界': "11",
-				'和': "010",
-				'平': "011",
-				'!': "00",
+				'和': "011",
+				'平': "00",
+				'!': "010",
 			},
 		},
 	}
@@ -421,3 +421,15 @@ func Test_newCharStat(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewTableFromHistogram(t* testing.T){
+	text := "My name is Ted, my name is Ned"
+	g := NewGenerator()
+
+	want := g.NewTable(text)
+	got := g.NewTableFromHistogram(table.NewHistogram(text, 1))
+
+	if !reflect.DeepEqual(got, want){
+		t.Errorf("NewTableFromHistogram() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package table
+
+import (
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+// minShardSize is the smallest part of text counted by a separate goroutine
+const minShardSize = 64 << 10
+
+// Histogram is characters frequencies
+type Histogram map[rune]int
+
+// ByteHistogram is bytes frequencies
+type ByteHistogram [256]int
+
+// shardCount counts ASCII characters in an array and the rest in a map
+type shardCount struct{
+	ascii [utf8.RuneSelf]int
+	other map[rune]int
+}
+
+
+// NewHistogram counts characters of text with up to threads goroutines,
+// 0 means the number of CPUs
+func NewHistogram(text string, threads int) Histogram{
+	shards := splitShards(text, threads)
+	counts := make([]shardCount, len(shards))
+
+	var wg sync.WaitGroup
+	for i, shard := range shards{
+		wg.Add(1)
+
+		go func(i int, shard string){
+			defer wg.Done()
+			counts[i] = countRunes(shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	res := make(Histogram)
+	for _, c := range counts{
+		for ch, qty := range c.ascii{
+			if qty != 0{
+				res[rune(ch)] += qty
+			}
+		}
+		res.Merge(c.other)
+	}
+
+	return res
+}
+
+// Merge adds frequencies of other to the histogram
+func (h Histogram) Merge(other Histogram){
+	for ch, qty := range other{
+		h[ch] += qty
+	}
+}
+
+// NewByteHistogram counts bytes of data with up to threads goroutines,
+// 0 means the number of CPUs
+func NewByteHistogram(data []byte, threads int) ByteHistogram{
+	shards := shardsCount(len(data), threads)
+	shardSize := (len(data) + shards - 1) / shards
+	counts := make([]ByteHistogram, shards)
+
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++{
+		wg.Add(1)
+
+		go func(i int){
+			defer wg.Done()
+
+			for _, b := range data[min(i*shardSize, len(data)):min((i+1)*shardSize, len(data))]{
+				counts[i][b]++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var res ByteHistogram
+	for _, c := range counts{
+		for b, qty := range c{
+			res[b] += qty
+		}
+	}
+
+	return res
+}
+
+
+// HistogramWriter counts characters of everything written to it,
+// so statistics can be gathered in a streaming pass
+type HistogramWriter struct{
+	threads int
+	hist Histogram
+	// incomplete character at the end of the last write
+	tail []byte
+}
+
+func NewHistogramWriter(threads int) *HistogramWriter{
+	return &HistogramWriter{threads: threads, hist: make(Histogram)}
+}
+
+func (w *HistogramWriter) Write(p []byte) (int, error){
+	data := append(w.tail, p...)
+
+	cut := len(data) - incompleteSuffix(data)
+	w.hist.Merge(NewHistogram(string(data[:cut]), w.threads))
+	w.tail = append([]byte{}, data[cut:]...)
+
+	return len(p), nil
+}
+
+// Histogram returns frequencies of written characters,
+// incomplete character at the end is counted as utf8.RuneError
+func (w *HistogramWriter) Histogram() Histogram{
+	res := make(Histogram, len(w.hist))
+	res.Merge(w.hist)
+	res.Merge(NewHistogram(string(w.tail), 1))
+
+	return res
+}
+
+
+func countRunes(text string) shardCount{
+	res := shardCount{other: make(map[rune]int)}
+
+	for _, ch := range text{
+		if ch < utf8.RuneSelf{
+			res.ascii[ch]++
+			continue
+		}
+		res.other[ch]++
+	}
+
+	return res
+}
+
+// splitShards splits text into parts at character boundaries
+func splitShards(text string, threads int) []string{
+	shards := shardsCount(len(text), threads)
+	shardSize := len(text) / shards
+
+	res := make([]string, 0, shards)
+	for i := 0; i < shards-1; i++{
+		end := min(shardSize, len(text))
+		for end < len(text) && end-shardSize < utf8.UTFMax && !utf8.RuneStart(text[end]){
+			end++
+		}
+
+		res = append(res, text[:end])
+		text = text[end:]
+	}
+
+	return append(res, text)
+}
+
+func shardsCount(size int, threads int) int{
+	if threads <= 0{
+		threads = runtime.NumCPU()
+	}
+
+	return max(min(threads, size/minShardSize), 1)
+}
+
+// incompleteSuffix returns the size of incomplete character at the end of data
+func incompleteSuffix(data []byte) int{
+	for i := len(data) - 1; i >= 0 && len(data)-i < utf8.UTFMax; i--{
+		if !utf8.RuneStart(data[i]){
+			continue
+		}
+		if utf8.FullRune(data[i:]){
+			return 0
+		}
+		return len(data) - i
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package table
+
+import (
+	"testing"
+	"reflect"
+	"strings"
+)
+
+func Test_NewHistogram(t* testing.T){
+	long := strings.Repeat("abc Жёлудь 世界 ", minShardSize/4)
+
+	tests := []struct{
+		name string
+		text string
+		threads int
+	}{
+		{name: "empty", text: "", threads: 4},
+		{name: "single thread", text: long, threads: 1},
+		{name: "many threads", text: long, threads: 7},
+		{name: "all CPUs", text: long, threads: 0},
+		{name: "invalid utf-8", text: "a\xffb" + long + "\xe4\xb8", threads: 3},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			want := make(Histogram)
+			for _, ch := range tt.text{
+				want[ch]++
+			}
+
+			if got := NewHistogram(tt.text, tt.threads); !reflect.DeepEqual(got, want){
+				t.Errorf("NewHistogram() = #%v#, want #%v#", got, want)
+			}
+		})
+	}
+}
+
+func Test_NewByteHistogram(t* testing.T){
+	data := []byte(strings.Repeat("abc\x00\xff", minShardSize))
+
+	var want ByteHistogram
+	for _, b := range data{
+		want[b]++
+	}
+
+	for _, threads := range []int{1, 3, 0}{
+		if got := NewByteHistogram(data, threads); got != want{
+			t.Errorf("NewByteHistogram(%d threads) = #%v#, want #%v#", threads, got, want)
+		}
+	}
+}
+
+func Test_HistogramWriter(t* testing.T){
+	text := "My name is Тед, 世界!"
+	want := NewHistogram(text, 1)
+
+	// every character is split between writes
+	w := NewHistogramWriter(1)
+	for i := 0; i < len(text); i++{
+		if _, err := w.Write([]byte{text[i]}); err != nil{
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if got := w.Histogram(); !reflect.DeepEqual(got, want){
+		t.Errorf("Histogram() = #%v#, want #%v#", got, want)
+	}
+
+	// incomplete character at the end
+	_, _ = w.Write([]byte("\xe4\xb8"))
+	want['�'] += 2
+
+	if got := w.Histogram(); !reflect.DeepEqual(got, want){
+		t.Errorf("Histogram() = #%v#, want #%v#", got, want)
+	}
+}
@@ -29,7 +29,12 @@ func NewGenerator() Generator{
 
 func (g Generator) NewTable(text string) table.EncodingTable{
 			
-		encTable := build(text)	
+		return g.NewTableFromHistogram(table.NewHistogram(text, 0))
+}
+
+func (g Generator) NewTableFromHistogram(hist table.Histogram) table.EncodingTable{
+
+		encTable := buildFromStat(charStat(hist))
 		return table.EncodingTable(encTable.Export())
 }
 
@@ -52,8 +57,10 @@ func (et encodingTable) Export() map[rune]string{
 
 
 func build(str string) encodingTable{
-	
-	stat := newCharStat(str)
+	return buildFromStat(newCharStat(str))
+}
+
+func buildFromStat(stat charStat) encodingTable{
 	codes := make([]code, 0, len(stat))
 
 	for ch, qty := range stat{
@@ -158,11 +165,5 @@ func abs(x int)int{
 
 func newCharStat(text string) charStat{
 
-	res := make(charStat)
-
-	for _, ch := range text{
-		res[ch]++
-	}
-	
-	return res
+	return charStat(table.NewHistogram(text, 0))
 }
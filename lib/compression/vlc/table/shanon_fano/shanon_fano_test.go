@@ -226,12 +226,14 @@ func Test_NewTable(t* testing.T){
 
 }
 
-		
-
-
-
-
-
-
+func Test_NewTableFromHistogram(t* testing.T){
+	text := "My name is Ted, my name is Ned"
+	g := NewGenerator()
 
+	want := g.NewTable(text)
+	got := g.NewTableFromHistogram(table.NewHistogram(text, 1))
 
+	if !reflect.DeepEqual(got, want){
+		t.Errorf("NewTableFromHistogram() = %v, want %v", got, want)
+	}
+}
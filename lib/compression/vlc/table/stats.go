@@ -0,0 +1,86 @@
+package table
+
+import (
+	"math"
+	"sort"
+)
+
+// CharCount is a character and its frequency
+type CharCount struct{
+	Char rune
+	Count int
+}
+
+// Total returns the number of characters counted
+func (h Histogram) Total() int{
+	total := 0
+	for _, qty := range h{
+		total += qty
+	}
+
+	return total
+}
+
+// Entropy returns Shannon entropy of the histogram in bits per character,
+// it is the lower bound of the average code length
+func (h Histogram) Entropy() float64{
+	total := float64(h.Total())
+
+	res := 0.0
+	for _, qty := range h{
+		if qty == 0{
+			continue
+		}
+
+		p := float64(qty) / total
+		res -= p * math.Log2(p)
+	}
+
+	return res
+}
+
+// Top returns up to n most frequent characters,
+// characters with equal frequencies are ordered by code point
+func (h Histogram) Top(n int) []CharCount{
+	res := make([]CharCount, 0, len(h))
+	for ch, qty := range h{
+		res = append(res, CharCount{Char: ch, Count: qty})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Count != res[j].Count{
+			return res[i].Count > res[j].Count
+		}
+
+		return res[i].Char < res[j].Char
+	})
+
+	return res[:min(n, len(res))]
+}
+
+// AverageCodeLength returns the average code length in bits per character
+// of text with histogram hist, characters missing in the table are escaped
+func (et EncodingTable) AverageCodeLength(hist Histogram) float64{
+	total := hist.Total()
+	if total == 0{
+		return 0
+	}
+
+	bits := 0
+	for ch, qty := range hist{
+		code, _ := et.Bin(ch)
+		bits += len(code) * qty
+	}
+
+	return float64(bits) / float64(total)
+}
+
+// MaxCodeLength returns the length of the longest code, the depth of the code tree
+func (et EncodingTable) MaxCodeLength() int{
+	res := 0
+	for _, code := range et{
+		res = max(res, len(code))
+	}
+
+	return res
+}
@@ -0,0 +1,59 @@
+package table
+
+import (
+	"testing"
+	"math"
+	"reflect"
+)
+
+func TestHistogram_Entropy(t* testing.T){
+	tests := []struct{
+		name string
+		hist Histogram
+		want float64
+	}{
+		{name: "empty", hist: Histogram{}, want: 0},
+		{name: "one char", hist: Histogram{'a': 10}, want: 0},
+		{name: "uniform", hist: Histogram{'a': 5, 'b': 5, 'c': 5, 'd': 5}, want: 2},
+		{name: "skewed", hist: Histogram{'a': 2, 'b': 1, 'c': 1}, want: 1.5},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if got := tt.hist.Entropy(); math.Abs(got - tt.want) > 1e-9{
+				t.Errorf("Entropy() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogram_Top(t* testing.T){
+	hist := Histogram{'a': 1, 'b': 3, 'c': 3, 'd': 2}
+
+	want := []CharCount{{'b', 3}, {'c', 3}, {'d', 2}}
+	if got := hist.Top(3); !reflect.DeepEqual(got, want){
+		t.Errorf("Top() = #%v#, want #%v#", got, want)
+	}
+
+	if got := hist.Top(10); len(got) != 4{
+		t.Errorf("Top() = #%v#, want 4 characters", got)
+	}
+}
+
+func TestEncodingTable_AverageCodeLength(t* testing.T){
+	tbl := EncodingTable{'a': "0", 'b': "10", 'c': "11", EscapeChar: "111"}
+	hist := Histogram{'a': 2, 'b': 1, 'c': 1}
+
+	if got := tbl.AverageCodeLength(hist); got != 1.5{
+		t.Errorf("AverageCodeLength() = #%v#, want #1.5#", got)
+	}
+
+	// escaped characters cost the escape code and the character
+	hist['x'] = 4
+	if got, want := tbl.AverageCodeLength(hist), (6.0 + 4 * (3 + EscapedCharSize)) / 8; got != want{
+		t.Errorf("AverageCodeLength() = #%v#, want #%v#", got, want)
+	}
+
+	if got := tbl.MaxCodeLength(); got != 3{
+		t.Errorf("MaxCodeLength() = #%v#, want #3#", got)
+	}
+}
@@ -0,0 +1,80 @@
+package table
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const storedMagic = "VLCT"
+
+var ErrInvalidStored = errors.New("invalid stored table")
+
+// Stored is a table trained on a corpus and kept outside of packed files,
+// packed files refer to it by ID
+type Stored struct{
+	ID uint32
+	Table EncodingTable
+}
+
+// Train builds a table for texts similar to the corpus with the histogram,
+// the table has EscapeChar code for characters missing in the corpus
+func Train(g Generator, corpus Histogram) EncodingTable{
+	hist := make(Histogram, len(corpus)+1)
+	hist.Merge(corpus)
+	hist[EscapeChar]++
+
+	return g.NewTableFromHistogram(hist)
+}
+
+// NewStored returns stored table, ID is a checksum of the table
+func NewStored(tbl EncodingTable) (Stored, error){
+	entries, err := tbl.MarshalBinary()
+	if err != nil{
+		return Stored{}, err
+	}
+
+	return Stored{ID: crc32.ChecksumIEEE(entries), Table: tbl}, nil
+}
+
+// MarshalBinary writes magic, ID and table entries sorted by character
+func (s Stored) MarshalBinary() ([]byte, error){
+	entries, err := s.Table.MarshalBinary()
+	if err != nil{
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(storedMagic)
+	buf.Write(binary.BigEndian.AppendUint32(nil, s.ID))
+	buf.Write(entries)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary reads the stored table, ID must be the checksum of table entries
+func (s *Stored) UnmarshalBinary(data []byte) error{
+	const headerSize = len(storedMagic) + 4
+
+	if len(data) < headerSize || string(data[:len(storedMagic)]) != storedMagic{
+		return fmt.Errorf("%w: bad magic", ErrInvalidStored)
+	}
+
+	id := binary.BigEndian.Uint32(data[len(storedMagic):headerSize])
+	if crc32.ChecksumIEEE(data[headerSize:]) != id{
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidStored)
+	}
+
+	var tbl EncodingTable
+	if err := tbl.UnmarshalBinary(data[headerSize:]); err != nil{
+		return fmt.Errorf("%w: %w", ErrInvalidStored, err)
+	}
+
+	s.ID = id
+	s.Table = tbl
+
+	return nil
+}
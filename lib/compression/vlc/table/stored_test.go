@@ -0,0 +1,64 @@
+package table
+
+import (
+	"testing"
+	"reflect"
+	"errors"
+)
+
+func Test_Stored_MarshalBinary(t* testing.T){
+	tbl := EncodingTable{
+		'a': "0",
+		'b': "10",
+		'c': "110",
+		EscapeChar: "111",
+	}
+
+	stored, err := NewStored(tbl)
+	if err != nil{
+		t.Fatalf("NewStored() error = %v", err)
+	}
+
+	data, err := stored.MarshalBinary()
+	if err != nil{
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	var got Stored
+	if err := got.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(got, stored){
+		t.Errorf("UnmarshalBinary() = #%v %v#, want #%v#", got, err, stored)
+	}
+
+	// damaged entries don't match the ID
+	data[len(data)-1] ^= 1
+	if err := got.UnmarshalBinary(data); !errors.Is(err, ErrInvalidStored){
+		t.Errorf("UnmarshalBinary() error = #%v#, want #%v#", err, ErrInvalidStored)
+	}
+
+	// the same table always gets the same ID
+	for i := 0; i < 10; i++{
+		again, _ := NewStored(EncodingTable{'c': "110", EscapeChar: "111", 'b': "10", 'a': "0"})
+		if again.ID != stored.ID{
+			t.Fatalf("NewStored() ID = #%08x#, want #%08x#", again.ID, stored.ID)
+		}
+	}
+}
+
+func Test_Stored_UnmarshalBinary_Errors(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "bad magic", data: []byte("ABCD\x00\x00\x00\x00")},
+		{name: "no entries", data: []byte("VLCT\x00\x00\x00\x00")},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			var s Stored
+			if err := s.UnmarshalBinary(tt.data); !errors.Is(err, ErrInvalidStored){
+				t.Errorf("UnmarshalBinary() error = #%v#, want #%v#", err, ErrInvalidStored)
+			}
+		})
+	}
+}
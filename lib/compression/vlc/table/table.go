@@ -1,15 +1,37 @@
 package table
 
 
-import "strings"
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sort"
+	"fmt"
+	"unicode/utf8"
 
+	"archiver/lib/compression"
+)
+
+// EscapeChar is added to pretrained tables,
+// its code is followed by EscapedCharSize bits of a character missing in the table
+const EscapeChar = utf8.MaxRune
+const EscapedCharSize = 21
+
+var ErrInvalidCode = errors.New("invalid code")
+var ErrInvalidTable = errors.New("invalid table")
 
 type Generator interface{
 	NewTable(text string) EncodingTable
+	NewTableFromHistogram(hist Histogram) EncodingTable
 }
 
 type EncodingTable map[rune]string
 
+type tableEntry struct{
+	Char rune
+	Code string
+}
+
 type decodingTree struct {
 	Data string
 	Left *decodingTree
@@ -17,7 +39,8 @@ type decodingTree struct {
 }
 
 
-func (et EncodingTable) Decode(text string)string{
+// Decode decodes binary string text, the text must end with a complete code
+func (et EncodingTable) Decode(text string) (string, error){
 	dt := et.decodingTree()
 
 	return dt.Decode(text)
@@ -62,30 +85,236 @@ func (ec EncodingTable)decodingTree() decodingTree{
 	return res
 }
 
-func (dt *decodingTree) Decode(bStr string) string{
+func (dt *decodingTree) Decode(bStr string) (string, error){
+	return dt.decode(len(bStr), func(i int) (byte, error){
+		switch bStr[i]{
+			case '0':
+				return 0, nil
+			case '1':
+				return 1, nil
+		}
+
+		return 0, fmt.Errorf("%w: %q at bit %d", ErrInvalidCode, bStr[i], i)
+	})
+}
+
+// DecodeBits decodes the first bits of data, bits of a byte are read from the highest,
+// the bits must end with a complete code
+func (et EncodingTable) DecodeBits(data []byte, bits int) (string, error){
+	if bits < 0 || bits > len(data) * 8{
+		return "", fmt.Errorf("%w: %d bits of %d bytes", ErrInvalidCode, bits, len(data))
+	}
+
+	dt := et.decodingTree()
+
+	return dt.decode(bits, func(i int) (byte, error){
+		return data[i/8] >> (7 - i%8) & 1, nil
+	})
+}
+
+// decode decodes n bits, bit returns the bit at i
+func (dt *decodingTree) decode(n int, bit func(i int) (byte, error)) (string, error){
 	var buf strings.Builder
 
+	escape := string(EscapeChar)
 	currentNode := dt
-	
-	for _, ch := range bStr{
-		
-		if currentNode.Data != ""{
-			buf.WriteString(currentNode.Data)
-			currentNode = dt
+
+	for i := 0; i < n; i++{
+		b, err := bit(i)
+		if err != nil{
+			return "", err
 		}
-		
-		switch ch{
-			case '0':
-				currentNode = currentNode.Left
-			case '1':
-				currentNode = currentNode.Right
+
+		if b == 0{
+			currentNode = currentNode.Left
+		}else{
+			currentNode = currentNode.Right
+		}
+
+		if currentNode == nil{
+			return "", fmt.Errorf("%w: no character ends at bit %d", ErrInvalidCode, i)
+		}
+
+		if currentNode.Data == ""{
+			continue
 		}
 
-	}	
-	if currentNode.Data != ""{
+		if currentNode.Data == escape{
+			if i+EscapedCharSize >= n{
+				return "", fmt.Errorf("%w: escaped character is truncated", ErrInvalidCode)
+			}
+
+			var ch rune
+			for j := i+1; j <= i+EscapedCharSize; j++{
+				b, err := bit(j)
+				if err != nil{
+					return "", fmt.Errorf("%w: escaped character at bit %d", ErrInvalidCode, i)
+				}
+				ch = ch<<1 | rune(b)
+			}
+			if !utf8.ValidRune(ch){
+				return "", fmt.Errorf("%w: escaped character at bit %d", ErrInvalidCode, i)
+			}
+			buf.WriteRune(ch)
+			i += EscapedCharSize
+		}else{
 			buf.WriteString(currentNode.Data)
-			currentNode = dt
+		}
+
+		currentNode = dt
+	}
+
+	if currentNode != dt{
+		return "", fmt.Errorf("%w: the last code is truncated", ErrInvalidCode)
+	}
+
+	return buf.String(), nil
+}
+
+// MarshalBinary serializes table entries sorted by character,
+// so equal tables always give equal bytes:
+// entries count | entries of character | code length in bits | code bits
+func (et EncodingTable) MarshalBinary() ([]byte, error){
+	if err := et.validate(); err != nil{
+		return nil, err
+	}
+	entries := et.entries()
+
+	res := binary.AppendUvarint(nil, uint64(len(entries)))
+	for _, e := range entries{
+		res = binary.AppendUvarint(res, uint64(e.Char))
+		res = binary.AppendUvarint(res, uint64(len(e.Code)))
+
+		code := make([]byte, (len(e.Code) + 7) / 8)
+		for i := 0; i < len(e.Code); i++{
+			if e.Code[i] == '1'{
+				code[i/8] |= 1 << (7 - i%8)
+			}
+		}
+		res = append(res, code...)
+	}
+
+	return res, nil
+}
+
+// entries returns table entries sorted by character
+func (et EncodingTable) entries() []tableEntry{
+	entries := make([]tableEntry, 0, len(et))
+	for ch, code := range et{
+		entries = append(entries, tableEntry{Char: ch, Code: code})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Char < entries[j].Char
+	})
+
+	return entries
+}
+
+func (et *EncodingTable) UnmarshalBinary(data []byte) error{
+	tbl, err := UnmarshalTable(data, compression.Limits{})
+	if err != nil{
+		return err
+	}
+	*et = tbl
+
+	return nil
+}
+
+// minEntrySize is the size of an entry with a character and a code of one byte
+const minEntrySize = 3
+
+// UnmarshalTable reads the table written by MarshalBinary,
+// the entries count is checked by limits before the table is allocated
+func UnmarshalTable(data []byte, limits compression.Limits) (EncodingTable, error){
+	count, n := binary.Uvarint(data)
+	if n <= 0{
+		return nil, fmt.Errorf("%w: entries count is truncated", ErrInvalidTable)
+	}
+	data = data[n:]
+
+	if count > uint64(len(data) / minEntrySize){
+		return nil, fmt.Errorf("%w: %d entries in %d bytes", ErrInvalidTable, count, len(data))
+	}
+	if err := limits.CheckSymbols(int(count)); err != nil{
+		return nil, err
+	}
+
+	tbl := make(EncodingTable, count)
+	for i := uint64(0); i < count; i++{
+		ch, n := binary.Uvarint(data)
+		if n <= 0 || ch > utf8.MaxRune{
+			return nil, fmt.Errorf("%w: character of entry %d", ErrInvalidTable, i)
+		}
+		data = data[n:]
+
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size == 0 || size > uint64(len(data) - n) * 8{
+			return nil, fmt.Errorf("%w: code of entry %d", ErrInvalidTable, i)
+		}
+		data = data[n:]
+
+		code := make([]byte, size)
+		for j := range code{
+			code[j] = '0' + data[j/8] >> (7 - j%8) & 1
+		}
+		data = data[(size + 7) / 8:]
+
+		if _, ok := tbl[rune(ch)]; ok{
+			return nil, fmt.Errorf("%w: duplicate character %q", ErrInvalidTable, rune(ch))
+		}
+		tbl[rune(ch)] = string(code)
+	}
+
+	if len(data) != 0{
+		return nil, fmt.Errorf("%w: %d bytes after entries", ErrInvalidTable, len(data))
+	}
+
+	if err := tbl.validate(); err != nil{
+		return nil, err
+	}
+
+	return tbl, nil
+}
+
+// validate checks that characters are valid and codes are non-empty binary strings
+// and no code is a prefix of another one, so decoding is unambiguous
+func (et EncodingTable) validate() error{
+	codes := make([]string, 0, len(et))
+
+	for ch, code := range et{
+		if !utf8.ValidRune(ch){
+			return fmt.Errorf("%w: character %d", ErrInvalidTable, ch)
+		}
+		if code == "" || strings.Trim(code, "01") != ""{
+			return fmt.Errorf("%w: code %q of %q", ErrInvalidTable, code, ch)
+		}
+		codes = append(codes, code)
+	}
+
+	// a code is followed by the codes it is a prefix of
+	sort.Strings(codes)
+	for i := 1; i < len(codes); i++{
+		if strings.HasPrefix(codes[i], codes[i-1]){
+			return fmt.Errorf("%w: code %q is a prefix of %q", ErrInvalidTable, codes[i-1], codes[i])
+		}
+	}
+
+	return nil
+}
+
+// Bin returns binary code of the character,
+// characters missing in the table are escaped if the table has EscapeChar
+func (et EncodingTable) Bin(ch rune) (string, bool){
+	if ch != EscapeChar{
+		if code, ok := et[ch]; ok{
+			return code, true
+		}
+	}
+
+	escape, ok := et[EscapeChar]
+	if !ok{
+		return "", false
 	}
 
-	return buf.String()
+	return escape + fmt.Sprintf("%0*b", EscapedCharSize, ch), true
 }
@@ -2,43 +2,46 @@ package table
 
 import (
 	"testing"
+	"errors"
 	"reflect"
+
+	"archiver/lib/compression"
 )
 
 
 func Test_BuildEncodingTree(t* testing.T){
 	tests := []struct{
 		name string
-		ec encodingTable
-		want DecodingTree
+		ec EncodingTable
+		want decodingTree
 	}{
 		{
 			name: "base test",
-			ec: encodingTable{
+			ec: EncodingTable{
 				'a': "11",
 				'b' : "1001",
  				'z' : "0101", 
 			},
-			want: DecodingTree{
-				Left: &DecodingTree{
-					Right: &DecodingTree{
-						Left: &DecodingTree{
-							Right: &DecodingTree{
+			want: decodingTree{
+				Left: &decodingTree{
+					Right: &decodingTree{
+						Left: &decodingTree{
+							Right: &decodingTree{
 								Data: "z",
 							},
 						},
 
 					},
 				},
-				Right: &DecodingTree{
-					Left: &DecodingTree{
-						Left: &DecodingTree{
-							Right: &DecodingTree{
+				Right: &decodingTree{
+					Left: &decodingTree{
+						Left: &decodingTree{
+							Right: &decodingTree{
 								Data: "b",
 							},
 						},
 					},
-					Right: &DecodingTree{
+					Right: &decodingTree{
 						Data: "a",
 					},
 				},
@@ -48,8 +51,8 @@ func Test_BuildEncodingTree(t* testing.T){
 	}
 	for _, tt := range tests{
 		t.Run(tt.name, func(t* testing.T){
-			if got := tt.ec.DecodingTree(); !reflect.DeepEqual(got, tt.want){
-				t.Errorf("DecodingTree() = #%v#, want #%v#", got, tt.want)
+			if got := tt.ec.decodingTree(); !reflect.DeepEqual(got, tt.want){
+				t.Errorf("decodingTree() = #%v#, want #%v#", got, tt.want)
 			}
 		})
 
@@ -57,3 +60,177 @@ func Test_BuildEncodingTree(t* testing.T){
 
 }
 
+
+func Test_EncodingTable_Decode(t* testing.T){
+	tests := []struct{
+		name string
+		et EncodingTable
+		str string
+		want string
+		wantErr error
+	}{
+		{
+			name: "base test",
+			et: EncodingTable{
+				'a': "0",
+				'b': "10",
+				'c': "11",
+			},
+			str: "01011",
+			want: "abc",
+		},
+		{
+			name: "missing code",
+			et: EncodingTable{
+				'a': "0",
+				'b': "10",
+			},
+			str: "011",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name: "truncated code",
+			et: EncodingTable{
+				'a': "0",
+				'b': "10",
+			},
+			str: "01",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name: "not a binary string",
+			et: EncodingTable{
+				'a': "0",
+				'b': "1",
+			},
+			str: "0x",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name: "truncated escaped character",
+			et: EncodingTable{
+				'a': "0",
+				EscapeChar: "1",
+			},
+			str: "0" + "1" + "0000",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name: "invalid escaped character",
+			et: EncodingTable{
+				'a': "0",
+				EscapeChar: "1",
+			},
+			str: "1" + "111111111111111111111",
+			wantErr: ErrInvalidCode,
+		},
+		{
+			name: "escaped character",
+			et: EncodingTable{
+				'a': "0",
+				EscapeChar: "1",
+			},
+			str: "0" + "1" + "000000000000001011010" + "0",
+			want: "aZa",
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, err := tt.et.Decode(tt.str)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("Decode() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if got != tt.want{
+				t.Errorf("Decode() = #%v#, want #%v#", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_EncodingTable_MarshalBinary_Invalid(t* testing.T){
+	tests := []struct{
+		name string
+		et EncodingTable
+	}{
+		{name: "empty code", et: EncodingTable{'a': "", 'b': "1"}},
+		{name: "not a binary code", et: EncodingTable{'a': "0", 'b': "12"}},
+		{name: "prefix code", et: EncodingTable{'a': "0", 'b': "01"}},
+		{name: "invalid character", et: EncodingTable{-1: "0", 'b': "1"}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := tt.et.MarshalBinary(); !errors.Is(err, ErrInvalidTable){
+				t.Errorf("MarshalBinary() error = #%v#, want #%v#", err, ErrInvalidTable)
+			}
+		})
+	}
+}
+
+func Test_EncodingTable_UnmarshalBinary_Invalid(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "empty code", data: []byte{1, 'a', 0, 0}},
+		{name: "prefix code", data: []byte{2, 'a', 1, 0x00, 'b', 2, 0x40}},
+		{name: "invalid character", data: []byte{1, 0x80, 0x80, 0x80, 0x01, 1, 0x80}},
+		{name: "surrogate character", data: []byte{1, 0x80, 0xb0, 0x03, 1, 0x80}},
+		{name: "duplicate character", data: []byte{2, 'a', 1, 0x00, 'a', 1, 0x80}},
+		{name: "truncated code", data: []byte{1, 'a', 9, 0x00}},
+		{name: "bytes after entries", data: []byte{1, 'a', 1, 0x00, 0x00}},
+		{name: "entries count", data: []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 'a', 1, 0x00}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			var got EncodingTable
+			if err := got.UnmarshalBinary(tt.data); !errors.Is(err, ErrInvalidTable){
+				t.Errorf("UnmarshalBinary() error = #%v#, want #%v#", err, ErrInvalidTable)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTable_Limits(t* testing.T){
+	et := EncodingTable{'a': "0", 'b': "10", 'c': "11"}
+
+	data, err := et.MarshalBinary()
+	if err != nil{
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	if got, err := UnmarshalTable(data, compression.Limits{MaxTableSymbols: 3}); err != nil || !reflect.DeepEqual(got, et){
+		t.Errorf("UnmarshalTable() = #%v %v#, want #%v#", got, err, et)
+	}
+	if _, err := UnmarshalTable(data, compression.Limits{MaxTableSymbols: 2}); !errors.Is(err, compression.ErrTooManySymbols){
+		t.Errorf("UnmarshalTable() error = #%v#, want #%v#", err, compression.ErrTooManySymbols)
+	}
+}
+
+func Test_EncodingTable_DecodeBits(t* testing.T){
+	et := EncodingTable{'a': "0", 'b': "10", EscapeChar: "11"}
+
+	tests := []struct{
+		name string
+		data []byte
+		bits int
+		want string
+		wantErr error
+	}{
+		{name: "base test", data: []byte{0b01000000}, bits: 3, want: "ab"},
+		{name: "escaped character", data: []byte{0b11000000, 0b00000000, 0b10110100}, bits: 23, want: "Z"},
+		{name: "truncated code", data: []byte{0b01000000}, bits: 2, wantErr: ErrInvalidCode},
+		{name: "bits out of data", data: []byte{0}, bits: 9, wantErr: ErrInvalidCode},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got, err := et.DecodeBits(tt.data, tt.bits)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("DecodeBits() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if got != tt.want{
+				t.Errorf("DecodeBits() = #%q#, want #%q#", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package table
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// CodeTree is the tree of codes of EncodingTable with character frequencies,
+// edge to Left is 0, edge to Right is 1
+type CodeTree struct{
+	// Char is set for leaves only
+	Char string `json:"char,omitempty"`
+	Code string `json:"code"`
+	Count int `json:"count"`
+	Left *CodeTree `json:"left,omitempty"`
+	Right *CodeTree `json:"right,omitempty"`
+}
+
+// NewCodeTree builds the code tree of et, frequencies are taken from hist,
+// an internal node counts all characters of its subtree
+func NewCodeTree(et EncodingTable, hist Histogram) *CodeTree{
+	root := &CodeTree{}
+
+	for _, entry := range et.entries(){
+		node := root
+		node.Count += hist[entry.Char]
+
+		for i, bit := range entry.Code{
+			next := &node.Left
+			if bit == '1'{
+				next = &node.Right
+			}
+
+			if *next == nil{
+				*next = &CodeTree{Code: entry.Code[:i+1]}
+			}
+			node = *next
+			node.Count += hist[entry.Char]
+		}
+
+		node.Char = charName(entry.Char)
+	}
+
+	return root
+}
+
+// WriteDOT writes the tree in Graphviz DOT language
+func (t *CodeTree) WriteDOT(w io.Writer) error{
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph codes {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+
+	id := 0
+	var walk func(t *CodeTree) int
+	walk = func(t *CodeTree) int{
+		nodeID := id
+		id++
+
+		if t.Left == nil && t.Right == nil{
+			label := fmt.Sprintf("%s\n%d\n%s", t.Char, t.Count, t.Code)
+			fmt.Fprintf(bw, "\tn%d [shape=box, label=%s];\n", nodeID, strconv.Quote(label))
+
+			return nodeID
+		}
+
+		fmt.Fprintf(bw, "\tn%d [label=\"%d\"];\n", nodeID, t.Count)
+
+		for bit, child := range []*CodeTree{t.Left, t.Right}{
+			if child != nil{
+				fmt.Fprintf(bw, "\tn%d -> n%d [label=\"%d\"];\n", nodeID, walk(child), bit)
+			}
+		}
+
+		return nodeID
+	}
+	walk(t)
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// Strings returns the table with characters as keys, e.g. for JSON
+func (et EncodingTable) Strings() map[string]string{
+	res := make(map[string]string, len(et))
+	for ch, code := range et{
+		res[charName(ch)] = code
+	}
+
+	return res
+}
+
+// charName returns the character, or ESC for the escape character
+func charName(ch rune) string{
+	if ch == EscapeChar{
+		return "ESC"
+	}
+
+	return string(ch)
+}
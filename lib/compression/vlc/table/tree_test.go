@@ -0,0 +1,41 @@
+package table
+
+import (
+	"testing"
+	"bytes"
+	"reflect"
+	"strings"
+)
+
+func TestNewCodeTree(t* testing.T){
+	tbl := EncodingTable{'a': "0", 'b': "10", 'c': "11"}
+	hist := Histogram{'a': 3, 'b': 2, 'c': 1}
+
+	want := &CodeTree{
+		Count: 6,
+		Left: &CodeTree{Char: "a", Code: "0", Count: 3},
+		Right: &CodeTree{
+			Code: "1",
+			Count: 3,
+			Left: &CodeTree{Char: "b", Code: "10", Count: 2},
+			Right: &CodeTree{Char: "c", Code: "11", Count: 1},
+		},
+	}
+
+	got := NewCodeTree(tbl, hist)
+	if !reflect.DeepEqual(got, want){
+		t.Errorf("NewCodeTree() = #%v#, want #%v#", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := got.WriteDOT(&buf); err != nil{
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+
+	dot := buf.String()
+	for _, s := range []string{"digraph codes {", `n0 [label="6"]`, `n0 -> n1 [label="0"]`, `n2 -> n4 [label="1"]`, `label="c\n1\n11"`}{
+		if !strings.Contains(dot, s){
+			t.Errorf("WriteDOT() = #%s#, want #%s#", dot, s)
+		}
+	}
+}
@@ -4,28 +4,71 @@ import (
 	"strings"
 	"bytes"
 	"encoding/binary"
-	"encoding/gob"
-	"log"
+	"errors"
+	"fmt"
+	"archiver/lib/compression"
 	"archiver/lib/compression/vlc/table"
 )
 
+// table kinds, the first byte of encoded file
+const (
+	inlineTable byte = iota
+	storedTable
+)
+
+var ErrUnknownChar = errors.New("unknown character")
+var ErrTableRequired = errors.New("file is packed with a pretrained table, table is not specified")
+var ErrTableMismatch = errors.New("file is packed with another pretrained table")
+var ErrInvalidBlock = errors.New("invalid block")
+var ErrInvalidText = errors.New("text is not valid UTF-8")
+
 
 type EncoderDecoder struct{
 	tblGenerator table.Generator	
+	stored *table.Stored
+	blockSize int
+	threads int
+	limits compression.Limits
 }
 	
 func New(tblGenerator table.Generator) EncoderDecoder{
-	return EncoderDecoder{tblGenerator: tblGenerator}
+	return EncoderDecoder{
+		tblGenerator: tblGenerator,
+		blockSize: DefaultBlockSize,
+		threads: 1,
+	}
+}
+
+// NewStatic returns EncoderDecoder which encodes with the pretrained table,
+// only the table ID is written to encoded files
+func NewStatic(stored table.Stored) EncoderDecoder{
+	return EncoderDecoder{
+		stored: &stored,
+		blockSize: DefaultBlockSize,
+		threads: 1,
+	}
 }
 
 
-func (ed EncoderDecoder) Encode(str string) []byte {
+// encodeBlock encodes block with its own table
+func (ed EncoderDecoder) encodeBlock(str string) ([]byte, error) {
+	if ed.stored != nil{
+		encoded, err := encodeBin(str, ed.stored.Table)
+		if err != nil{
+			return nil, err
+		}
+
+		return buildStoredEncodeFile(ed.stored.ID, encoded), nil
+	}
 
 	
 //haffman or shanon-fano table
 	table := ed.tblGenerator.NewTable(str)
 		
-	encoded := encodeBin(str, table)
+	encoded, err := encodeBin(str, table)
+	if err != nil{
+		return nil, err
+	}
 
 	return buildEncodeFile(table, encoded)
 }
@@ -36,39 +79,64 @@ func (ed EncoderDecoder) Encode(str string) []byte {
 // i.g.: M -> !m
 
 
-func buildEncodeFile(tbl table.EncodingTable, data string) []byte{
-	encodedTable := encodeTable(tbl)
+func buildEncodeFile(tbl table.EncodingTable, data string) ([]byte, error){
+	encodedTable, err := encodeTable(tbl)
+	if err != nil{
+		return nil, err
+	}
 
 	var buf bytes.Buffer
 
 
+	buf.WriteByte(inlineTable)
 	buf.Write(encodeInt(len(encodedTable)))
 	buf.Write(encodeInt(len(data)))
 	buf.Write(encodedTable)
 	buf.Write(splitByChunks(data, chunkSize).Bytes())
 	
 
-	return buf.Bytes()
+	return buf.Bytes(), nil
 
 }
 
+func buildStoredEncodeFile(tableID uint32, data string) []byte{
+	var buf bytes.Buffer
 
-func (ed EncoderDecoder) Decode(encData []byte) string{
-	//
-	table, data  := parseFile(encData)
+	buf.WriteByte(storedTable)
+	buf.Write(encodeInt(int(tableID)))
+	buf.Write(encodeInt(len(data)))
+	buf.Write(splitByChunks(data, chunkSize).Bytes())
 
-	return table.Decode(data)
-	
+	return buf.Bytes()
+}
+
+
+func (ed EncoderDecoder) decodeBlock(encData []byte) (string, error){
+	table, data, bits, err := parseFile(encData, ed.stored, ed.limits)
+	if err != nil{
+		return "", err
+	}
+
+	return table.DecodeBits(data, bits)
 }
 
 
 
-func parseFile(data []byte) (table table.EncodingTable, date string){
+// parseFile returns table, encoded data and the number of its bits,
+// stored is used for files packed with a pretrained table.
+// Table symbols are checked by limits before the table is read.
+func parseFile(data []byte, stored *table.Stored, limits compression.Limits) (table.EncodingTable, []byte, int, error){
 	const (
 		tableSizeBytesCount = 4
 		dataSizeBytesCount = 4
 	)
 
+	if len(data) < 1 + tableSizeBytesCount + dataSizeBytesCount{
+		return nil, nil, 0, fmt.Errorf("%w: header is truncated", ErrInvalidBlock)
+	}
+
+	kind, data := data[0], data[1:]
+
 	tableSizeBinary, data := data[:tableSizeBytesCount], data[tableSizeBytesCount:]
 	dataSizeBinary, data := data[:dataSizeBytesCount], data[dataSizeBytesCount:]
 
@@ -76,10 +144,45 @@ func parseFile(data []byte) (table table.EncodingTable, date string){
 	tableSize := binary.BigEndian.Uint32(tableSizeBinary)
 	dataSize := binary.BigEndian.Uint32(dataSizeBinary)
 
+	switch kind{
+		case storedTable:
+			// table size is replaced with table ID
+			switch{
+				case stored == nil:
+					return nil, nil, 0, ErrTableRequired
+				case stored.ID != tableSize:
+					return nil, nil, 0, fmt.Errorf("%w: file table %08x, given table %08x", ErrTableMismatch, tableSize, stored.ID)
+			}
+			tableSize = 0
+		case inlineTable:
+			if uint64(tableSize) > uint64(len(data)){
+				return nil, nil, 0, fmt.Errorf("%w: table of %d bytes, %d bytes left", ErrInvalidBlock, tableSize, len(data))
+			}
+		default:
+			return nil, nil, 0, fmt.Errorf("%w: unknown table kind %d", ErrInvalidBlock, kind)
+	}
+
+	if uint64(dataSize) > uint64(len(data) - int(tableSize)) * 8{
+		return nil, nil, 0, fmt.Errorf("%w: %d bits of data, %d bytes left", ErrInvalidBlock, dataSize, len(data) - int(tableSize))
+	}
+
+	if kind == storedTable{
+		if err := limits.CheckSymbols(len(stored.Table)); err != nil{
+			return nil, nil, 0, err
+		}
+
+		return stored.Table, data, int(dataSize), nil
+	}
+
 	tblBinary, data := data[:tableSize], data[tableSize:]
 	
 	
-	return decodeTable(tblBinary), NewBinChunks(data).ToString()[:dataSize]
+	tbl, err := table.UnmarshalTable(tblBinary, limits)
+	if err != nil{
+		return nil, nil, 0, err
+	}
+
+	return tbl, data, int(dataSize), nil
 } 
 
 
@@ -93,56 +196,38 @@ func encodeInt(num int) []byte{
 }
 
 
-func encodeTable(tbl table.EncodingTable) []byte{
-	var tableBuf bytes.Buffer
-
-
-	if err := gob.NewEncoder(&tableBuf).Encode(tbl); err != nil{
-		log.Fatal("can't serialize table...", err)
-	}
-
-
-	return tableBuf.Bytes()
-}
-
-
-func decodeTable(tblBinary []byte) (table.EncodingTable) {
-	var tbl table.EncodingTable
-
-
-	r := bytes.NewReader(tblBinary)
-	if err := gob.NewDecoder(r).Decode(&tbl); err != nil{
-		log.Fatal("can't serialize table...", err)
-	}
-
-
-	return tbl
+func encodeTable(tbl table.EncodingTable) ([]byte, error){
+	return tbl.MarshalBinary()
 }
 
 
 
 //encodeBin encodes string into binary codes string withou spaces
-func encodeBin(str string, table table.EncodingTable) string{
+func encodeBin(str string, table table.EncodingTable) (string, error){
 	var buf strings.Builder
 
 	
 	for _, ch := range str{
-		buf.WriteString(bin(ch, table))
+		code, err := bin(ch, table)
+		if err != nil{
+			return "", err
+		}
+		buf.WriteString(code)
 	}
 
 	
-	return buf.String()
+	return buf.String(), nil
 }	
 
 // bin uses character as a key for encodeTable and returns its binary code
-func bin(ch rune, table table.EncodingTable) string{
+func bin(ch rune, table table.EncodingTable) (string, error){
 
-	res, ok := table[ch]
+	res, ok := table.Bin(ch)
 	if !ok{
-		panic("unknown character: " + "\\" + string(ch) + "\\")
+		return "", fmt.Errorf("%w: %q", ErrUnknownChar, ch)
 	}
 
-	return res
+	return res, nil
 }
 
 
@@ -3,48 +3,46 @@ package vlc
 import (
 	"testing"
 	"reflect"
-)
-
+	"errors"
 
+	"archiver/lib/compression"
+	"archiver/lib/compression/vlc/table"
+	"archiver/lib/compression/vlc/table/shanon_fano"
+)
 
-func Test_prepareText(t* testing.T){
-	tests := []struct{
-		name string
-		str string
-		want string
-	}{  
-		{
-			name: "base test",
-			str: "My name is Ted",
-			want: "!my name is !ted",
-		},
-	}
-	for _, tt := range tests{
-		t.Run(tt.name, func(t* testing.T){
-			if got := prepareText(tt.str); got != tt.want{
-				t.Errorf("prepareText() = #%v#, want #%v#", got, tt.want)
-			}
-		})
-
-	}
 
-}
 
 func Test_encodeBin(t* testing.T){
 	tests := []struct{
 		name string
 		str string
+		tbl table.EncodingTable
 		want string
 	}{
 		{
 			name: "base test",
 			str: "!ted",
+			tbl: table.EncodingTable{
+				'!': "001000",
+				't': "1001",
+				'e': "101",
+				'd': "00101",
+			},
 			want: "001000100110100101",
 		},
+		{
+			name: "escaped character",
+			str: "tZ",
+			tbl: table.EncodingTable{
+				't': "1",
+				table.EscapeChar: "0",
+			},
+			want: "1" + "0" + "000000000000001011010",
+		},
 	}
 	for _, tt := range tests{
 		t.Run(tt.name, func(t* testing.T){
-			if got := encodeBin(tt.str); got != tt.want{
+			if got, err := encodeBin(tt.str, tt.tbl); err != nil || got != tt.want{
 				t.Errorf("encodeBin() = #%v#, want #%v#", got, tt.want)
 			}
 		})
@@ -57,19 +55,33 @@ func TestEncode(t* testing.T){
 	tests := []struct{
 		name string
 		str string
-		want []byte
 	}{
 		{
 			name: "base test",
 			str: "My name is Ted",
-			want: []byte{32, 48, 60, 24, 119, 74, 228, 77, 40},
 		},
 	}
 	for _, tt := range tests{
 		t.Run(tt.name, func(t* testing.T){
-			encoder := New()
-			if got := encoder.Encode(tt.str); !reflect.DeepEqual(got, tt.want){
-				t.Errorf("Encode() = #%v#, want #%v#", got, tt.want)
+			gen := shanon_fano.NewGenerator()
+			encoder := New(gen)
+
+			wantTbl := gen.NewTable(tt.str)
+			wantData, _ := encodeBin(tt.str, wantTbl)
+
+			encoded, err := encoder.encodeBlock(tt.str)
+			if err != nil{
+				t.Fatalf("encodeBlock() error = %v", err)
+			}
+
+			gotTbl, gotBytes, bits, err := parseFile(encoded, nil, compression.Limits{})
+			if err != nil{
+				t.Fatalf("parseFile() error = %v", err)
+			}
+
+			gotData := NewBinChunks(gotBytes).ToString()[:bits]
+			if !reflect.DeepEqual(gotTbl, wantTbl) || gotData != wantData{
+				t.Errorf("encodeBlock() = #%v %v#, want #%v %v#", gotTbl, gotData, wantTbl, wantData)
 			}
 		})
 
@@ -88,14 +100,14 @@ func TestDecode(t* testing.T){
 	}{
 		{
 			name: "base test",
-			str: []byte{32, 48, 60, 24, 119, 74, 228, 77, 40},
+			str: mustEncode(t, "My name is Ted"),
 			want: "My name is Ted",
 		},
 	}
 	for _, tt := range tests{
 		t.Run(tt.name, func(t* testing.T){
-			decoder := New()
-			if got := decoder.Decode(tt.str); !reflect.DeepEqual(tt.want, got){
+			decoder := New(shanon_fano.NewGenerator())
+			if got, err := decoder.Decode(tt.str); err != nil || !reflect.DeepEqual(tt.want, got){
 				t.Errorf("Decode() = #%v#, want #%v#", got, tt.want)
 			}
 		})
@@ -103,3 +115,68 @@ func TestDecode(t* testing.T){
 	}
 
 }
+
+func Test_encodeBin_UnknownChar(t* testing.T){
+	if _, err := encodeBin("ab", table.EncodingTable{'a': "0"}); !errors.Is(err, ErrUnknownChar){
+		t.Errorf("encodeBin() error = #%v#, want #%v#", err, ErrUnknownChar)
+	}
+}
+
+func TestStatic(t* testing.T){
+	trained := table.Train(shanon_fano.NewGenerator(), table.NewHistogram("my name is ted, my name is ned", 1))
+	stored, err := table.NewStored(trained)
+	if err != nil{
+		t.Fatalf("NewStored() error = %v", err)
+	}
+
+	tests := []struct{
+		name string
+		str string
+	}{
+		{name: "known characters", str: "my name is ned"},
+		{name: "unseen characters", str: "My name is Зед \U0010FFFF"},
+		{name: "empty", str: ""},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			ed := NewStatic(stored)
+
+			encoded, err := ed.Encode(tt.str)
+			if err != nil{
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			if got, err := ed.Decode(encoded); err != nil || got != tt.str{
+				t.Errorf("Decode() = #%v %v#, want #%v#", got, err, tt.str)
+			}
+		})
+	}
+}
+
+func TestStatic_Errors(t* testing.T){
+	stored, _ := table.NewStored(table.Train(shanon_fano.NewGenerator(), table.NewHistogram("abc", 1)))
+	other, _ := table.NewStored(table.Train(shanon_fano.NewGenerator(), table.NewHistogram("xyz", 1)))
+
+	encoded, err := NewStatic(stored).Encode("cab")
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if _, err := New(shanon_fano.NewGenerator()).Decode(encoded); !errors.Is(err, ErrTableRequired){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrTableRequired)
+	}
+	if _, err := NewStatic(other).Decode(encoded); !errors.Is(err, ErrTableMismatch){
+		t.Errorf("Decode() error = #%v#, want #%v#", err, ErrTableMismatch)
+	}
+}
+
+func mustEncode(t* testing.T, str string) []byte{
+	t.Helper()
+
+	encoded, err := New(shanon_fano.NewGenerator()).Encode(str)
+	if err != nil{
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	return encoded
+}
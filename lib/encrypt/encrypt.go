@@ -0,0 +1,411 @@
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// encryptedMagic starts encrypted files
+const encryptedMagic = "GOAE"
+
+const version = 1
+
+const (
+	// KeySize is the size of random file key and derived keys
+	KeySize = 32
+	// DefaultChunkSize is the size of plaintext chunks sealed separately
+	DefaultChunkSize = 64 << 10
+	// maxChunkSize limits memory used by readers
+	maxChunkSize = 16 << 20
+
+	// chunk nonce is nonce prefix | chunk counter | last chunk flag
+	noncePrefixSize = 7
+	nonceSize = noncePrefixSize + 4 + 1
+	tagSize = 16
+)
+
+// Cipher is AEAD the payload is encrypted with
+type Cipher byte
+
+const (
+	AES256GCM Cipher = iota + 1
+	ChaCha20Poly1305
+)
+
+var ErrInvalidHeader = errors.New("invalid encryption header")
+var ErrUnknownCipher = errors.New("unknown cipher")
+var ErrNoIdentity = errors.New("no identity matches the file")
+var ErrAuthentication = errors.New("data is damaged or altered")
+var ErrTruncated = errors.New("encrypted data is truncated")
+
+// ParseCipher returns cipher by name: aes-256-gcm, chacha20-poly1305
+func ParseCipher(name string) (Cipher, error){
+	switch name{
+		case "aes-256-gcm":
+			return AES256GCM, nil
+		case "chacha20-poly1305":
+			return ChaCha20Poly1305, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnknownCipher, name)
+}
+
+func (c Cipher) String() string{
+	switch c{
+		case AES256GCM:
+			return "aes-256-gcm"
+		case ChaCha20Poly1305:
+			return "chacha20-poly1305"
+	}
+
+	return fmt.Sprintf("cipher(%d)", byte(c))
+}
+
+func (c Cipher) newAEAD(key []byte) (cipher.AEAD, error){
+	switch c{
+		case AES256GCM:
+			block, err := aes.NewCipher(key)
+			if err != nil{
+				return nil, err
+			}
+			return cipher.NewGCM(block)
+		case ChaCha20Poly1305:
+			return chacha20poly1305.New(key)
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnknownCipher, byte(c))
+}
+
+// Stanza is the file key wrapped for one recipient
+type Stanza struct{
+	Type byte
+	Body []byte
+}
+
+// Recipient wraps the file key, the file can be decrypted by the matching Identity
+type Recipient interface{
+	Wrap(fileKey []byte) (Stanza, error)
+}
+
+// Identity unwraps the file key from a stanza,
+// ErrNoIdentity is returned for stanzas of other recipients
+type Identity interface{
+	Unwrap(s Stanza) ([]byte, error)
+}
+
+// IsEncrypted reports whether data starts as encrypted file
+func IsEncrypted(data []byte) bool{
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+
+// Writer encrypts data in chunks:
+// magic | version | cipher | chunk size | nonce prefix | stanzas | sealed chunks.
+// The header is authenticated with every chunk, the last chunk is marked,
+// so reordered, removed or appended chunks are detected.
+type Writer struct{
+	w io.Writer
+	aead cipher.AEAD
+	header []byte
+	nonce [nonceSize]byte
+	counter uint32
+	buf []byte
+	chunkSize int
+	err error
+}
+
+// NewWriter writes the header with the random file key wrapped for every recipient,
+// Close must be called to write the last chunk
+func NewWriter(w io.Writer, c Cipher, recipients ...Recipient) (*Writer, error){
+	if len(recipients) == 0 || len(recipients) > 255{
+		return nil, fmt.Errorf("%d recipients, want 1-255", len(recipients))
+	}
+
+	fileKey := make([]byte, KeySize)
+	if _, err := rand.Read(fileKey); err != nil{
+		return nil, err
+	}
+
+	aead, err := c.newAEAD(fileKey)
+	if err != nil{
+		return nil, err
+	}
+
+	ew := &Writer{w: w, aead: aead, chunkSize: DefaultChunkSize}
+	if _, err := rand.Read(ew.nonce[:noncePrefixSize]); err != nil{
+		return nil, err
+	}
+
+	header := append([]byte(encryptedMagic), version, byte(c))
+	header = binary.BigEndian.AppendUint32(header, uint32(ew.chunkSize))
+	header = append(header, ew.nonce[:noncePrefixSize]...)
+	header = append(header, byte(len(recipients)))
+
+	stanzas := make([]Stanza, 0, len(recipients))
+	for _, r := range recipients{
+		s, err := r.Wrap(fileKey)
+		if err != nil{
+			return nil, err
+		}
+		if len(s.Body) > 0xffff{
+			return nil, fmt.Errorf("%w: stanza is too large", ErrInvalidHeader)
+		}
+		stanzas = append(stanzas, s)
+	}
+	if err := checkStanzas(stanzas); err != nil{
+		return nil, err
+	}
+
+	for _, s := range stanzas{
+		header = append(header, s.Type)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(s.Body)))
+		header = append(header, s.Body...)
+	}
+
+	ew.header = header
+	if _, err := w.Write(header); err != nil{
+		return nil, err
+	}
+
+	return ew, nil
+}
+
+func (ew *Writer) Write(p []byte) (int, error){
+	if ew.err != nil{
+		return 0, ew.err
+	}
+
+	n := len(p)
+	for len(p) > 0{
+		// a full chunk is sealed only when more data follows,
+		// the last chunk is sealed by Close
+		if len(ew.buf) == ew.chunkSize{
+			ew.seal(false)
+			if ew.err != nil{
+				return 0, ew.err
+			}
+		}
+
+		m := min(ew.chunkSize - len(ew.buf), len(p))
+		ew.buf = append(ew.buf, p[:m]...)
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// Close seals the last chunk, it doesn't close the underlying writer
+func (ew *Writer) Close() error{
+	if ew.err != nil{
+		return ew.err
+	}
+
+	ew.seal(true)
+
+	return ew.err
+}
+
+func (ew *Writer) seal(last bool){
+	nonce := chunkNonce(ew.nonce, ew.counter, last)
+	ew.counter++
+
+	if ew.counter == 0{
+		ew.err = errors.New("too many chunks")
+		return
+	}
+
+	_, ew.err = ew.w.Write(ew.aead.Seal(nil, nonce, ew.buf, ew.header))
+	ew.buf = ew.buf[:0]
+}
+
+func chunkNonce(prefix [nonceSize]byte, counter uint32, last bool) []byte{
+	nonce := prefix
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last{
+		nonce[nonceSize-1] = 1
+	}
+
+	return nonce[:]
+}
+
+
+// Reader decrypts data written by Writer
+type Reader struct{
+	r *bufio.Reader
+	aead cipher.AEAD
+	header []byte
+	nonce [nonceSize]byte
+	counter uint32
+	chunkSize int
+	buf []byte
+	done bool
+	err error
+}
+
+// NewReader reads the header and unwraps the file key with the first matching identity
+func NewReader(r io.Reader, identities ...Identity) (*Reader, error){
+	br := bufio.NewReader(r)
+
+	// magic | version | cipher | chunk size | nonce prefix | stanzas count
+	fixed := make([]byte, len(encryptedMagic) + 1 + 1 + 4 + noncePrefixSize + 1)
+	if _, err := io.ReadFull(br, fixed); err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	if !IsEncrypted(fixed) || fixed[4] != version{
+		return nil, fmt.Errorf("%w: unknown format", ErrInvalidHeader)
+	}
+
+	c := Cipher(fixed[5])
+	chunkSize := int(binary.BigEndian.Uint32(fixed[6:]))
+	if chunkSize <= 0 || chunkSize > maxChunkSize{
+		return nil, fmt.Errorf("%w: chunk size %d", ErrInvalidHeader, chunkSize)
+	}
+
+	er := &Reader{r: br, chunkSize: chunkSize}
+	copy(er.nonce[:], fixed[10:10+noncePrefixSize])
+
+	header := fixed
+	stanzas := make([]Stanza, fixed[len(fixed)-1])
+
+	for i := range stanzas{
+		var head [3]byte
+		if _, err := io.ReadFull(br, head[:]); err != nil{
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+
+		body := make([]byte, binary.BigEndian.Uint16(head[1:]))
+		if _, err := io.ReadFull(br, body); err != nil{
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+		}
+
+		stanzas[i] = Stanza{Type: head[0], Body: body}
+		header = append(append(header, head[:]...), body...)
+	}
+	er.header = header
+
+	if err := checkStanzas(stanzas); err != nil{
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+
+	fileKey, err := unwrap(stanzas, identities)
+	if err != nil{
+		return nil, err
+	}
+
+	if er.aead, err = c.newAEAD(fileKey); err != nil{
+		return nil, err
+	}
+
+	return er, nil
+}
+
+// unwrap returns the file key of the first stanza matching any identity
+func unwrap(stanzas []Stanza, identities []Identity) ([]byte, error){
+	for _, id := range identities{
+		for _, s := range stanzas{
+			fileKey, err := id.Unwrap(s)
+			if errors.Is(err, ErrNoIdentity){
+				continue
+			}
+			if err != nil{
+				return nil, err
+			}
+
+			return fileKey, nil
+		}
+	}
+
+	return nil, ErrNoIdentity
+}
+
+func (er *Reader) Read(p []byte) (int, error){
+	for len(er.buf) == 0{
+		if er.err != nil{
+			return 0, er.err
+		}
+		if er.done{
+			return 0, io.EOF
+		}
+
+		er.open()
+	}
+
+	n := copy(p, er.buf)
+	er.buf = er.buf[n:]
+
+	return n, nil
+}
+
+// open reads and opens the next chunk, the chunk is the last one
+// if nothing follows it
+func (er *Reader) open(){
+	sealed := make([]byte, er.chunkSize + tagSize)
+
+	n, err := io.ReadFull(er.r, sealed)
+	switch{
+		case err == io.EOF:
+			er.err = ErrTruncated
+			return
+		case err != nil && err != io.ErrUnexpectedEOF:
+			er.err = err
+			return
+	}
+
+	_, peekErr := er.r.Peek(1)
+	last := n < len(sealed) || peekErr == io.EOF
+
+	er.buf, er.err = er.aead.Open(nil, chunkNonce(er.nonce, er.counter, last), sealed[:n], er.header)
+	if er.err != nil{
+		er.err = ErrAuthentication
+
+		// a full chunk at the end opened as a middle one means the last chunk is cut off
+		if last && n == len(sealed){
+			if _, err := er.aead.Open(nil, chunkNonce(er.nonce, er.counter, false), sealed[:n], er.header); err == nil{
+				er.err = ErrTruncated
+			}
+		}
+		return
+	}
+
+	er.counter++
+	er.done = last
+}
+
+
+// Encrypt returns data encrypted for recipients
+func Encrypt(data []byte, c Cipher, recipients ...Recipient) ([]byte, error){
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, c, recipients...)
+	if err != nil{
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil{
+		return nil, err
+	}
+	if err := w.Close(); err != nil{
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decrypt returns data decrypted with the first matching identity
+func Decrypt(data []byte, identities ...Identity) ([]byte, error){
+	r, err := NewReader(bytes.NewReader(data), identities...)
+	if err != nil{
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
@@ -0,0 +1,151 @@
+package encrypt
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"math/rand"
+)
+
+// plainKey is a test recipient and identity keeping the file key as is
+type plainKey struct{}
+
+func (plainKey) Wrap(fileKey []byte) (Stanza, error){
+	return Stanza{Type: 200, Body: fileKey}, nil
+}
+
+func (plainKey) Unwrap(s Stanza) ([]byte, error){
+	if s.Type != 200{
+		return nil, ErrNoIdentity
+	}
+
+	return s.Body, nil
+}
+
+func randomData(size int) []byte{
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+
+	return data
+}
+
+func TestEncrypt(t* testing.T){
+	for _, c := range []Cipher{AES256GCM, ChaCha20Poly1305}{
+		for _, size := range []int{0, 1, DefaultChunkSize - 1, DefaultChunkSize, DefaultChunkSize + 1, 3 * DefaultChunkSize}{
+			data := randomData(size)
+
+			encrypted, err := Encrypt(data, c, plainKey{})
+			if err != nil{
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+
+			decrypted, err := Decrypt(encrypted, plainKey{})
+			if err != nil || !bytes.Equal(decrypted, data){
+				t.Errorf("Decrypt() %v size %d error = %v, data equal = %v", c, size, err, bytes.Equal(decrypted, data))
+			}
+		}
+	}
+}
+
+func TestDecrypt_Errors(t* testing.T){
+	data := randomData(2 * DefaultChunkSize + 100)
+
+	encrypted, err := Encrypt(data, AES256GCM, plainKey{})
+	if err != nil{
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	headerSize := len(encrypted) - len(data) - 3 * tagSize
+	chunk := DefaultChunkSize + tagSize
+
+	modify := func(f func(data []byte) []byte) []byte{
+		return f(append([]byte(nil), encrypted...))
+	}
+
+	tests := []struct{
+		name string
+		data []byte
+		wantErr error
+	}{
+		{name: "altered chunk", data: modify(func(d []byte) []byte{ d[headerSize+10] ^= 1; return d }), wantErr: ErrAuthentication},
+		{name: "altered header", data: modify(func(d []byte) []byte{ d[8] ^= 1; return d }), wantErr: ErrAuthentication},
+		{name: "last chunk removed", data: encrypted[:headerSize+2*chunk], wantErr: ErrTruncated},
+		{name: "no chunks", data: encrypted[:headerSize], wantErr: ErrTruncated},
+		{name: "chunks swapped", data: modify(func(d []byte) []byte{
+			first := append([]byte(nil), d[headerSize:headerSize+chunk]...)
+			copy(d[headerSize:], d[headerSize+chunk:headerSize+2*chunk])
+			copy(d[headerSize+chunk:], first)
+			return d
+		}), wantErr: ErrAuthentication},
+		{name: "appended data", data: append(append([]byte(nil), encrypted...), 0), wantErr: ErrAuthentication},
+		{name: "not encrypted", data: []byte("plain data, not encrypted"), wantErr: ErrInvalidHeader},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := Decrypt(tt.data, plainKey{}); !errors.Is(err, tt.wantErr){
+				t.Errorf("Decrypt() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPassword(t* testing.T){
+	data := []byte("customer data")
+
+	for _, kdf := range []KDF{Argon2id, Scrypt}{
+		encrypted, err := Encrypt(data, ChaCha20Poly1305, PasswordRecipient{Password: []byte("secret"), KDF: kdf})
+		if err != nil{
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+
+		decrypted, err := Decrypt(encrypted, PasswordIdentity{Password: []byte("secret")})
+		if err != nil || !bytes.Equal(decrypted, data){
+			t.Errorf("Decrypt() = #%q %v#, want #%q#", decrypted, err, data)
+		}
+
+		if _, err := Decrypt(encrypted, PasswordIdentity{Password: []byte("wrong")}); !errors.Is(err, ErrWrongPassword){
+			t.Errorf("Decrypt() error = #%v#, want #%v#", err, ErrWrongPassword)
+		}
+
+		if _, err := Decrypt(encrypted, plainKey{}); !errors.Is(err, ErrNoIdentity){
+			t.Errorf("Decrypt() error = #%v#, want #%v#", err, ErrNoIdentity)
+		}
+	}
+}
+
+func TestPassword_HostileHeader(t* testing.T){
+	data := []byte("customer data")
+	password := PasswordIdentity{Password: []byte("secret")}
+
+	// both stanzas are made password stanzas
+	encrypted, err := Encrypt(data, AES256GCM, plainKey{}, plainKey{})
+	if err != nil{
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	first := len(encryptedMagic) + 1 + 1 + 4 + noncePrefixSize + 1
+	encrypted[first] = passwordStanza
+	encrypted[first + 3 + KeySize] = passwordStanza
+
+	if _, err := Decrypt(encrypted, password); !errors.Is(err, ErrInvalidHeader) || !errors.Is(err, ErrPasswordStanza){
+		t.Errorf("Decrypt() error = #%v#, want #%v#", err, ErrPasswordStanza)
+	}
+
+	// KDF parameters costlier than Wrap writes
+	tests := []struct{
+		name string
+		params []byte
+	}{
+		{name: "argon2id time", params: []byte{byte(Argon2id), 0, 0, 0, argon2Time + 1, 0, 1, 0, 0, argon2Threads}},
+		{name: "argon2id memory", params: []byte{byte(Argon2id), 0, 0, 0, argon2Time, 0, 1, 0, 1, argon2Threads}},
+		{name: "scrypt N", params: []byte{byte(Scrypt), scryptLogN + 1, 0, 0, 0, scryptR, 0, 0, 0, scryptP}},
+		{name: "scrypt p", params: []byte{byte(Scrypt), scryptLogN, 0, 0, 0, scryptR, 0, 0, 0, scryptP + 1}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			body := append(append(tt.params, make([]byte, saltSize)...), make([]byte, KeySize + tagSize)...)
+
+			if _, err := password.Unwrap(Stanza{Type: passwordStanza, Body: body}); !errors.Is(err, ErrInvalidHeader){
+				t.Errorf("Unwrap() error = #%v#, want #%v#", err, ErrInvalidHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// stanza types
+const (
+	passwordStanza byte = iota + 1
+)
+
+// KDF derives the key wrapping the file key from a password
+type KDF byte
+
+const (
+	Argon2id KDF = iota + 1
+	Scrypt
+)
+
+const saltSize = 16
+
+// default and the largest accepted KDF parameters, the largest ones are
+// the defaults Wrap writes, so hostile headers can't make key derivation costlier
+const (
+	argon2Time = 3
+	argon2Memory = 64 << 10 // KiB
+	argon2Threads = 4
+	maxArgon2Time = argon2Time
+	maxArgon2Memory = argon2Memory
+
+	scryptLogN = 15
+	scryptR = 8
+	scryptP = 1
+	maxScryptLogN = scryptLogN
+	maxScryptR = scryptR
+	maxScryptP = scryptP
+)
+
+var ErrUnknownKDF = errors.New("unknown key derivation function")
+var ErrWrongPassword = errors.New("wrong password")
+var ErrPasswordStanza = errors.New("password stanza must be the only stanza")
+
+// ParseKDF returns KDF by name: argon2id, scrypt
+func ParseKDF(name string) (KDF, error){
+	switch name{
+		case "argon2id":
+			return Argon2id, nil
+		case "scrypt":
+			return Scrypt, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrUnknownKDF, name)
+}
+
+// PasswordRecipient wraps the file key with a key derived from Password
+type PasswordRecipient struct{
+	Password []byte
+	KDF KDF
+}
+
+// PasswordIdentity unwraps the file key wrapped by PasswordRecipient
+type PasswordIdentity struct{
+	Password []byte
+}
+
+// Wrap writes stanza: KDF | KDF parameters | salt | sealed file key
+func (pr PasswordRecipient) Wrap(fileKey []byte) (Stanza, error){
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil{
+		return Stanza{}, err
+	}
+
+	body := []byte{byte(pr.KDF)}
+	switch pr.KDF{
+		case Argon2id:
+			body = binary.BigEndian.AppendUint32(body, argon2Time)
+			body = binary.BigEndian.AppendUint32(body, argon2Memory)
+			body = append(body, argon2Threads)
+		case Scrypt:
+			body = append(body, scryptLogN)
+			body = binary.BigEndian.AppendUint32(body, scryptR)
+			body = binary.BigEndian.AppendUint32(body, scryptP)
+		default:
+			return Stanza{}, fmt.Errorf("%w: %d", ErrUnknownKDF, byte(pr.KDF))
+	}
+	body = append(body, salt...)
+
+	key, err := deriveKey(pr.Password, body)
+	if err != nil{
+		return Stanza{}, err
+	}
+
+	return Stanza{Type: passwordStanza, Body: sealKey(key, fileKey, body)}, nil
+}
+
+func (pi PasswordIdentity) Unwrap(s Stanza) ([]byte, error){
+	if s.Type != passwordStanza{
+		return nil, ErrNoIdentity
+	}
+
+	sealedSize := KeySize + chacha20poly1305.Overhead
+	if len(s.Body) < sealedSize{
+		return nil, fmt.Errorf("%w: password stanza is too short", ErrInvalidHeader)
+	}
+	params, sealed := s.Body[:len(s.Body)-sealedSize], s.Body[len(s.Body)-sealedSize:]
+
+	key, err := deriveKey(pi.Password, params)
+	if err != nil{
+		return nil, err
+	}
+
+	fileKey, err := openKey(key, sealed, params)
+	if err != nil{
+		return nil, ErrWrongPassword
+	}
+
+	return fileKey, nil
+}
+
+// deriveKey derives the key from password with KDF parameters and salt of params
+func deriveKey(password, params []byte) ([]byte, error){
+	if len(params) < 1{
+		return nil, fmt.Errorf("%w: KDF is missing", ErrInvalidHeader)
+	}
+
+	kdf, params := KDF(params[0]), params[1:]
+
+	switch kdf{
+		case Argon2id:
+			if len(params) != 9 + saltSize{
+				return nil, fmt.Errorf("%w: argon2id parameters", ErrInvalidHeader)
+			}
+
+			time, memory, threads := binary.BigEndian.Uint32(params), binary.BigEndian.Uint32(params[4:]), params[8]
+			if time == 0 || time > maxArgon2Time || memory == 0 || memory > maxArgon2Memory || threads == 0{
+				return nil, fmt.Errorf("%w: argon2id parameters t=%d m=%d p=%d", ErrInvalidHeader, time, memory, threads)
+			}
+
+			return argon2.IDKey(password, params[9:], time, memory, threads, KeySize), nil
+		case Scrypt:
+			if len(params) != 9 + saltSize{
+				return nil, fmt.Errorf("%w: scrypt parameters", ErrInvalidHeader)
+			}
+
+			logN, r, p := params[0], binary.BigEndian.Uint32(params[1:]), binary.BigEndian.Uint32(params[5:])
+			if logN == 0 || logN > maxScryptLogN || r == 0 || r > maxScryptR || p == 0 || p > maxScryptP{
+				return nil, fmt.Errorf("%w: scrypt parameters N=2^%d r=%d p=%d", ErrInvalidHeader, logN, r, p)
+			}
+
+			return scrypt.Key(password, params[9:], 1 << logN, int(r), int(p), KeySize)
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrUnknownKDF, byte(kdf))
+}
+
+// checkStanzas allows one password stanza only without other stanzas,
+// so a header can't make readers derive keys from the password many times
+func checkStanzas(stanzas []Stanza) error{
+	for _, s := range stanzas{
+		if s.Type == passwordStanza && len(stanzas) > 1{
+			return ErrPasswordStanza
+		}
+	}
+
+	return nil
+}
+
+// sealKey returns ad followed by fileKey sealed with the wrapping key,
+// the wrapping key is never reused, so the zero nonce is safe
+func sealKey(key, fileKey, ad []byte) []byte{
+	aead, _ := chacha20poly1305.New(key)
+
+	return aead.Seal(append([]byte(nil), ad...), make([]byte, chacha20poly1305.NonceSize), fileKey, ad)
+}
+
+func openKey(key, sealed, ad []byte) ([]byte, error){
+	aead, err := chacha20poly1305.New(key)
+	if err != nil{
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), sealed, ad)
+}
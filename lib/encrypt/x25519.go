@@ -0,0 +1,225 @@
+package encrypt
+
+import (
+	"bufio"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const x25519Stanza byte = 2
+
+// key text prefixes
+const (
+	x25519PublicPrefix = "archiver-x25519:"
+	x25519SecretPrefix = "ARCHIVER-X25519-SECRET:"
+)
+
+const x25519Info = "archiver x25519"
+
+var ErrInvalidKey = errors.New("invalid key")
+
+// X25519Recipient wraps the file key to an X25519 public key:
+// the wrapping key is derived from the shared secret of an ephemeral key
+type X25519Recipient struct{
+	key *ecdh.PublicKey
+}
+
+// X25519Identity unwraps the file key wrapped to its public key
+type X25519Identity struct{
+	key *ecdh.PrivateKey
+}
+
+// GenerateX25519Identity returns a new random identity
+func GenerateX25519Identity() (*X25519Identity, error){
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil{
+		return nil, err
+	}
+
+	return &X25519Identity{key: key}, nil
+}
+
+// ParseX25519Recipient parses the public key returned by X25519Recipient.String
+func ParseX25519Recipient(s string) (*X25519Recipient, error){
+	data, err := parseKey(s, x25519PublicPrefix)
+	if err != nil{
+		return nil, err
+	}
+
+	key, err := ecdh.X25519().NewPublicKey(data)
+	if err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return &X25519Recipient{key: key}, nil
+}
+
+// ParseX25519Identity parses the secret key returned by X25519Identity.String
+func ParseX25519Identity(s string) (*X25519Identity, error){
+	data, err := parseKey(s, x25519SecretPrefix)
+	if err != nil{
+		return nil, err
+	}
+
+	key, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return &X25519Identity{key: key}, nil
+}
+
+// ParseRecipients parses public keys, one per line,
+// empty lines and lines starting with # are skipped
+func ParseRecipients(r io.Reader) ([]Recipient, error){
+	var res []Recipient
+
+	err := parseLines(r, func(line string) error{
+		recipient, err := ParseX25519Recipient(line)
+		if err != nil{
+			return err
+		}
+		res = append(res, recipient)
+
+		return nil
+	})
+
+	return res, err
+}
+
+// ParseIdentities parses secret keys, one per line,
+// empty lines and lines starting with # are skipped
+func ParseIdentities(r io.Reader) ([]Identity, error){
+	var res []Identity
+
+	err := parseLines(r, func(line string) error{
+		identity, err := ParseX25519Identity(line)
+		if err != nil{
+			return err
+		}
+		res = append(res, identity)
+
+		return nil
+	})
+
+	return res, err
+}
+
+func (r *X25519Recipient) String() string{
+	return x25519PublicPrefix + base64.RawURLEncoding.EncodeToString(r.key.Bytes())
+}
+
+func (i *X25519Identity) String() string{
+	return x25519SecretPrefix + base64.RawURLEncoding.EncodeToString(i.key.Bytes())
+}
+
+// Recipient returns the public key of the identity
+func (i *X25519Identity) Recipient() *X25519Recipient{
+	return &X25519Recipient{key: i.key.PublicKey()}
+}
+
+// Wrap writes stanza: ephemeral public key | sealed file key
+func (r *X25519Recipient) Wrap(fileKey []byte) (Stanza, error){
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil{
+		return Stanza{}, err
+	}
+
+	shared, err := ephemeral.ECDH(r.key)
+	if err != nil{
+		return Stanza{}, err
+	}
+
+	key, err := x25519WrapKey(shared, ephemeral.PublicKey().Bytes(), r.key.Bytes())
+	if err != nil{
+		return Stanza{}, err
+	}
+
+	return Stanza{Type: x25519Stanza, Body: sealKey(key, fileKey, ephemeral.PublicKey().Bytes())}, nil
+}
+
+func (i *X25519Identity) Unwrap(s Stanza) ([]byte, error){
+	if s.Type != x25519Stanza{
+		return nil, ErrNoIdentity
+	}
+
+	if len(s.Body) != 32 + KeySize + chacha20poly1305.Overhead{
+		return nil, fmt.Errorf("%w: x25519 stanza size %d", ErrInvalidHeader, len(s.Body))
+	}
+	ephemeralBytes, sealed := s.Body[:32], s.Body[32:]
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralBytes)
+	if err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	shared, err := i.key.ECDH(ephemeral)
+	if err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHeader, err)
+	}
+
+	key, err := x25519WrapKey(shared, ephemeralBytes, i.key.PublicKey().Bytes())
+	if err != nil{
+		return nil, err
+	}
+
+	fileKey, err := openKey(key, sealed, ephemeralBytes)
+	if err != nil{
+		// the stanza is wrapped to another recipient
+		return nil, ErrNoIdentity
+	}
+
+	return fileKey, nil
+}
+
+// x25519WrapKey derives the wrapping key from the shared secret and both public keys
+func x25519WrapKey(shared, ephemeral, recipient []byte) ([]byte, error){
+	salt := append(append([]byte(nil), ephemeral...), recipient...)
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(x25519Info)), key); err != nil{
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func parseKey(s, prefix string) ([]byte, error){
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix){
+		return nil, fmt.Errorf("%w: want %s prefix", ErrInvalidKey, prefix)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s[len(prefix):])
+	if err != nil{
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return data, nil
+}
+
+func parseLines(r io.Reader, parse func(line string) error) error{
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++{
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#"){
+			continue
+		}
+
+		if err := parse(line); err != nil{
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,71 @@
+package encrypt
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+func TestX25519(t* testing.T){
+	ops, err := GenerateX25519Identity()
+	if err != nil{
+		t.Fatal(err)
+	}
+	qa, _ := GenerateX25519Identity()
+	other, _ := GenerateX25519Identity()
+
+	data := []byte("build artifact")
+
+	encrypted, err := Encrypt(data, AES256GCM, ops.Recipient(), qa.Recipient())
+	if err != nil{
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	for _, id := range []*X25519Identity{ops, qa}{
+		decrypted, err := Decrypt(encrypted, id)
+		if err != nil || !bytes.Equal(decrypted, data){
+			t.Errorf("Decrypt() = #%q %v#, want #%q#", decrypted, err, data)
+		}
+	}
+
+	if _, err := Decrypt(encrypted, other); !errors.Is(err, ErrNoIdentity){
+		t.Errorf("Decrypt() error = #%v#, want #%v#", err, ErrNoIdentity)
+	}
+
+	// a password can't be combined with public keys
+	if _, err := Encrypt(data, AES256GCM, ops.Recipient(), PasswordRecipient{Password: []byte("secret"), KDF: Scrypt}); !errors.Is(err, ErrPasswordStanza){
+		t.Errorf("Encrypt() error = #%v#, want #%v#", err, ErrPasswordStanza)
+	}
+}
+
+func TestParseKeys(t* testing.T){
+	id, _ := GenerateX25519Identity()
+
+	keys := "# ops team\n" + id.Recipient().String() + "\n\n" + id.Recipient().String() + "\n"
+	recipients, err := ParseRecipients(strings.NewReader(keys))
+	if err != nil || len(recipients) != 2 || recipients[0].(*X25519Recipient).String() != id.Recipient().String(){
+		t.Errorf("ParseRecipients() = #%v %v#, want 2 keys", recipients, err)
+	}
+
+	identities, err := ParseIdentities(strings.NewReader(id.String()))
+	if err != nil || len(identities) != 1 || identities[0].(*X25519Identity).String() != id.String(){
+		t.Errorf("ParseIdentities() = #%v %v#, want the key", identities, err)
+	}
+
+	tests := []struct{
+		name string
+		key string
+	}{
+		{name: "secret as public", key: id.String()},
+		{name: "bad encoding", key: x25519PublicPrefix + "***"},
+		{name: "short key", key: x25519PublicPrefix + "AAAA"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := ParseRecipients(strings.NewReader(tt.key)); !errors.Is(err, ErrInvalidKey){
+				t.Errorf("ParseRecipients() error = #%v#, want #%v#", err, ErrInvalidKey)
+			}
+		})
+	}
+}
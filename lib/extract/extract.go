@@ -0,0 +1,335 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"archiver/lib/filter"
+)
+
+// Policy decides what happens to files which already exist in the destination
+type Policy int
+
+const (
+	// Fail stops extraction with ErrExists at an existing file
+	Fail Policy = iota
+	// Overwrite replaces existing files
+	Overwrite
+	// NoOverwrite keeps existing files and skips their entries
+	NoOverwrite
+	// KeepNewer keeps existing files modified after their entries
+	KeepNewer
+)
+
+var ErrUnsafePath = errors.New("unsafe path")
+var ErrUnsafeLink = errors.New("symlink points outside the destination")
+var ErrExists = errors.New("conflicting file exists")
+
+// Extractor writes files of fs.FS into the destination directory.
+// Entries are never written outside of it: names escaping the destination,
+// paths through symlinks and symlinks pointing outside are rejected.
+type Extractor struct{
+	dir string
+	stripComponents int
+	policy Policy
+	filter filter.Filter
+	// onSkip is called with names of skipped entries
+	onSkip func(name string)
+}
+
+// NewExtractor returns Extractor writing into dir, existing files are errors
+func NewExtractor(dir string) Extractor{
+	return Extractor{dir: dir}
+}
+
+// WithStripComponents returns Extractor which removes n leading elements of entry names,
+// entries with no more than n elements are skipped
+func (e Extractor) WithStripComponents(n int) Extractor{
+	e.stripComponents = max(n, 0)
+
+	return e
+}
+
+// WithPolicy returns Extractor which handles existing files by policy
+func (e Extractor) WithPolicy(policy Policy) Extractor{
+	e.policy = policy
+
+	return e
+}
+
+// WithFilter returns Extractor which decodes file contents with f
+func (e Extractor) WithFilter(f filter.Filter) Extractor{
+	e.filter = f
+
+	return e
+}
+
+// WithOnSkip returns Extractor which calls f with the name of every entry it doesn't write:
+// entries kept by the policy and entries of other types. Entries removed by strip components
+// are asked to be dropped, so they aren't reported.
+func (e Extractor) WithOnSkip(f func(name string)) Extractor{
+	e.onSkip = f
+
+	return e
+}
+
+// Extract writes directories, regular files and symlinks of fsys,
+// other entries are skipped
+func (e Extractor) Extract(fsys fs.FS) error{
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error{
+		if err != nil || name == "."{
+			return err
+		}
+
+		rel, ok := e.strip(name)
+		if !ok{
+			return nil
+		}
+		if !filepath.IsLocal(rel){
+			return fmt.Errorf("%w: %q", ErrUnsafePath, name)
+		}
+		if err := e.checkParents(rel); err != nil{
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil{
+			return err
+		}
+
+		switch{
+			case info.IsDir():
+				return e.writeDir(name, rel, info)
+			case info.Mode().IsRegular():
+				return e.writeFile(fsys, name, rel, info)
+			case info.Mode()&fs.ModeSymlink != 0:
+				return e.writeLink(fsys, name, rel, info)
+		}
+
+		// devices, pipes and sockets are skipped
+		e.skip(name)
+		return nil
+	})
+}
+
+func (e Extractor) skip(name string){
+	if e.onSkip != nil{
+		e.onSkip(name)
+	}
+}
+
+// strip removes leading elements of slash-separated name
+// and returns the path relative to the destination
+func (e Extractor) strip(name string) (string, bool){
+	elems := strings.Split(name, "/")
+	if len(elems) <= e.stripComponents{
+		return "", false
+	}
+
+	return filepath.FromSlash(path.Join(elems[e.stripComponents:]...)), true
+}
+
+// checkParents checks that no parent directory of rel is a symlink,
+// so nothing is written through a link out of the destination
+func (e Extractor) checkParents(rel string) error{
+	parent := e.dir
+
+	elems := strings.Split(rel, string(filepath.Separator))
+	for _, elem := range elems[:len(elems)-1]{
+		parent = filepath.Join(parent, elem)
+
+		info, err := os.Lstat(parent)
+		if errors.Is(err, fs.ErrNotExist){
+			return nil
+		}
+		if err != nil{
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0{
+			return fmt.Errorf("%w: %q is a symlink", ErrUnsafePath, parent)
+		}
+	}
+
+	return nil
+}
+
+// writeDir makes the directory, an existing file is replaced by the policy,
+// the directory is skipped with its entries if the policy keeps the file
+func (e Extractor) writeDir(name, rel string, info fs.FileInfo) error{
+	path := filepath.Join(e.dir, rel)
+
+	existing, err := os.Lstat(path)
+	switch{
+		case err == nil && existing.IsDir():
+			return nil
+		case err == nil:
+			ok, err := e.replace(name, path, info)
+			if err != nil{
+				return err
+			}
+			if !ok{
+				return fs.SkipDir
+			}
+			if err := os.Remove(path); err != nil{
+				return err
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+	}
+
+	return os.MkdirAll(path, info.Mode().Perm() | 0700)
+}
+
+// writeFile decodes the entry before the existing file is touched,
+// the file is written to a temporary file renamed over it
+func (e Extractor) writeFile(fsys fs.FS, name, rel string, info fs.FileInfo) error{
+	path := filepath.Join(e.dir, rel)
+
+	if ok, err := e.replace(name, path, info); !ok || err != nil{
+		return err
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil{
+		return err
+	}
+	if e.filter != nil{
+		e.filter.Decode(data)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil{
+		return err
+	}
+
+	// the temporary file is created anew and renaming replaces an existing link,
+	// so the link is never followed
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil{
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil{
+		err = closeErr
+	}
+	if err != nil{
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil{
+		return err
+	}
+	if err := os.Chtimes(f.Name(), info.ModTime(), info.ModTime()); err != nil{
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func (e Extractor) writeLink(fsys fs.FS, name, rel string, info fs.FileInfo) error{
+	target, err := readLink(fsys, name)
+	if err != nil{
+		return err
+	}
+
+	if !localLink(rel, target){
+		return fmt.Errorf("%w: %q -> %q", ErrUnsafeLink, name, target)
+	}
+
+	path := filepath.Join(e.dir, rel)
+
+	if ok, err := e.replace(name, path, info); !ok || err != nil{
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil{
+		return err
+	}
+
+	// the link is made under a temporary name and renamed over the existing file
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil{
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	if err := os.Remove(tmp); err != nil{
+		return err
+	}
+
+	if err := os.Symlink(filepath.FromSlash(target), tmp); err != nil{
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil{
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// readLinkFS is fs.FS which reads symlink targets without following them
+type readLinkFS interface{
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// readLink returns the target of the symlink, file systems
+// without ReadLink store it as the contents of the link
+func readLink(fsys fs.FS, name string) (string, error){
+	if rl, ok := fsys.(readLinkFS); ok{
+		return rl.ReadLink(name)
+	}
+
+	target, err := fs.ReadFile(fsys, name)
+
+	return string(target), err
+}
+
+// replace reports whether the existing file at path may be replaced by the entry name,
+// it returns false if the policy skips the entry. The file is kept until the entry is written.
+func (e Extractor) replace(name, path string, info fs.FileInfo) (bool, error){
+	existing, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist){
+		return true, nil
+	}
+	if err != nil{
+		return false, err
+	}
+
+	if existing.IsDir(){
+		return false, fmt.Errorf("%w: %q is a directory", ErrExists, path)
+	}
+
+	switch e.policy{
+		case Fail:
+			return false, fmt.Errorf("%w: %q", ErrExists, path)
+		case NoOverwrite:
+			e.skip(name)
+			return false, nil
+		case KeepNewer:
+			if existing.ModTime().After(info.ModTime()){
+				e.skip(name)
+				return false, nil
+			}
+	}
+
+	return true, nil
+}
+
+// localLink reports whether the symlink at rel with slash-separated target
+// points into the destination. Target must be relative and clean,
+// so ".." may only lead it and never follows another link.
+func localLink(rel, target string) bool{
+	if target == "" || path.IsAbs(target) || filepath.IsAbs(target) || path.Clean(target) != target{
+		return false
+	}
+
+	return filepath.IsLocal(filepath.Join(filepath.Dir(rel), filepath.FromSlash(target)))
+}
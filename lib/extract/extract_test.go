@@ -0,0 +1,300 @@
+package extract
+
+import (
+	"testing"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing/fstest"
+	"time"
+)
+
+var modTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func file(data string) *fstest.MapFile{
+	return &fstest.MapFile{Data: []byte(data), Mode: 0644, ModTime: modTime}
+}
+
+func link(target string) *fstest.MapFile{
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0777, ModTime: modTime}
+}
+
+func TestExtract(t* testing.T){
+	fsys := fstest.MapFS{
+		"root/a.txt": file("a"),
+		"root/sub/b.txt": file("b"),
+		"root/sub/link": link("../a.txt"),
+		"root/empty": &fstest.MapFile{Mode: fs.ModeDir | 0755},
+	}
+
+	tests := []struct{
+		name string
+		strip int
+		want map[string]string
+	}{
+		{
+			name: "base test",
+			want: map[string]string{"root/a.txt": "a", "root/sub/b.txt": "b", "root/sub/link": "a"},
+		},
+		{
+			name: "strip components",
+			strip: 1,
+			want: map[string]string{"a.txt": "a", "sub/b.txt": "b", "sub/link": "a"},
+		},
+		{
+			name: "strip everything",
+			strip: 3,
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			dir := t.TempDir()
+
+			skipped := 0
+			x := NewExtractor(dir).WithStripComponents(tt.strip).WithOnSkip(func(string){ skipped++ })
+			if err := x.Extract(fsys); err != nil{
+				t.Fatalf("Extract() error = %v", err)
+			}
+			// stripped entries are dropped deliberately
+			if skipped != 0{
+				t.Errorf("Extract() skipped %d entries, want none", skipped)
+			}
+
+			for name, want := range tt.want{
+				got, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil || string(got) != want{
+					t.Errorf("%s = #%q %v#, want #%q#", name, got, err, want)
+				}
+			}
+
+			if len(tt.want) == 0{
+				if entries, _ := os.ReadDir(dir); len(entries) != 0{
+					t.Errorf("Extract() wrote %d entries, want none", len(entries))
+				}
+			}
+		})
+	}
+}
+
+func TestExtract_Malicious(t* testing.T){
+	tests := []struct{
+		name string
+		fsys fstest.MapFS
+		policy Policy
+		prepare func(t* testing.T, dir, outside string)
+		wantErr error
+	}{
+		{
+			name: "absolute symlink",
+			fsys: fstest.MapFS{"link": link("/etc/passwd")},
+			wantErr: ErrUnsafeLink,
+		},
+		{
+			name: "symlink out of the destination",
+			fsys: fstest.MapFS{"sub/link": link("../../secret")},
+			wantErr: ErrUnsafeLink,
+		},
+		{
+			name: "symlink through another link",
+			fsys: fstest.MapFS{"link": link("sub/../..")},
+			wantErr: ErrUnsafeLink,
+		},
+		{
+			name: "directory over existing symlink",
+			fsys: fstest.MapFS{"out/passwd": file("root")},
+			prepare: func(t* testing.T, dir, outside string){
+				if err := os.Symlink(outside, filepath.Join(dir, "out")); err != nil{
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrExists,
+		},
+		{
+			name: "directory over kept symlink",
+			fsys: fstest.MapFS{"out/passwd": file("root")},
+			policy: NoOverwrite,
+			prepare: func(t* testing.T, dir, outside string){
+				if err := os.Symlink(outside, filepath.Join(dir, "out")); err != nil{
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "directory over existing file",
+			fsys: fstest.MapFS{"a": &fstest.MapFile{Mode: fs.ModeDir | 0755}},
+			prepare: func(t* testing.T, dir, outside string){
+				if err := os.WriteFile(filepath.Join(dir, "a"), nil, 0644); err != nil{
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrExists,
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			dir, outside := t.TempDir(), t.TempDir()
+			if tt.prepare != nil{
+				tt.prepare(t, dir, outside)
+			}
+
+			err := NewExtractor(dir).WithPolicy(tt.policy).Extract(tt.fsys)
+			if !errors.Is(err, tt.wantErr){
+				t.Errorf("Extract() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+
+			if entries, _ := os.ReadDir(outside); len(entries) != 0{
+				t.Errorf("Extract() wrote %d entries outside of the destination", len(entries))
+			}
+		})
+	}
+}
+
+func TestExtract_Policy(t* testing.T){
+	fsys := fstest.MapFS{"a.txt": file("new")}
+
+	tests := []struct{
+		name string
+		policy Policy
+		existingTime time.Time
+		want string
+		wantSkipped bool
+		wantErr error
+	}{
+		{name: "fail", policy: Fail, existingTime: modTime.Add(-time.Hour), want: "old", wantErr: ErrExists},
+		{name: "overwrite", policy: Overwrite, existingTime: modTime.Add(time.Hour), want: "new"},
+		{name: "no overwrite", policy: NoOverwrite, existingTime: modTime.Add(-time.Hour), want: "old", wantSkipped: true},
+		{name: "keep newer", policy: KeepNewer, existingTime: modTime.Add(time.Hour), want: "old", wantSkipped: true},
+		{name: "replace older", policy: KeepNewer, existingTime: modTime.Add(-time.Hour), want: "new"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			dir := t.TempDir()
+			path := filepath.Join(dir, "a.txt")
+
+			if err := os.WriteFile(path, []byte("old"), 0644); err != nil{
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, tt.existingTime, tt.existingTime); err != nil{
+				t.Fatal(err)
+			}
+
+			var skipped []string
+			x := NewExtractor(dir).WithPolicy(tt.policy).WithOnSkip(func(name string){ skipped = append(skipped, name) })
+			if err := x.Extract(fsys); !errors.Is(err, tt.wantErr){
+				t.Fatalf("Extract() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil || string(got) != tt.want{
+				t.Errorf("a.txt = #%q %v#, want #%q#", got, err, tt.want)
+			}
+			if (len(skipped) > 0) != tt.wantSkipped{
+				t.Errorf("Extract() skipped = #%v#, want skipped %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestExtract_DirPolicy(t* testing.T){
+	fsys := fstest.MapFS{
+		"sub": &fstest.MapFile{Mode: fs.ModeDir | 0755, ModTime: modTime},
+		"sub/a.txt": file("new"),
+	}
+
+	tests := []struct{
+		name string
+		policy Policy
+		wantDir bool
+		wantSkipped []string
+		wantErr error
+	}{
+		{name: "fail", policy: Fail, wantErr: ErrExists},
+		{name: "overwrite", policy: Overwrite, wantDir: true},
+		{name: "no overwrite", policy: NoOverwrite, wantSkipped: []string{"sub"}},
+		{name: "keep newer", policy: KeepNewer, wantSkipped: []string{"sub"}},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			dir := t.TempDir()
+			path := filepath.Join(dir, "sub")
+
+			// the existing file is newer than the directory entry
+			if err := os.WriteFile(path, []byte("old"), 0644); err != nil{
+				t.Fatal(err)
+			}
+
+			var skipped []string
+			x := NewExtractor(dir).WithPolicy(tt.policy).WithOnSkip(func(name string){ skipped = append(skipped, name) })
+			if err := x.Extract(fsys); !errors.Is(err, tt.wantErr){
+				t.Fatalf("Extract() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+
+			info, err := os.Lstat(path)
+			if err != nil || info.IsDir() != tt.wantDir{
+				t.Errorf("sub = #%v %v#, want directory %v", info, err, tt.wantDir)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped){
+				t.Errorf("Extract() skipped = #%v#, want #%v#", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+// brokenFS fails to read files as a damaged archive does
+type brokenFS struct{
+	fstest.MapFS
+}
+
+func (brokenFS) ReadFile(name string) ([]byte, error){
+	return nil, errDamaged
+}
+
+var errDamaged = errors.New("damaged entry")
+
+func TestExtract_KeepsFileOnError(t* testing.T){
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil{
+		t.Fatal(err)
+	}
+
+	fsys := brokenFS{fstest.MapFS{"a.txt": file("new")}}
+	if err := NewExtractor(dir).WithPolicy(Overwrite).Extract(fsys); !errors.Is(err, errDamaged){
+		t.Fatalf("Extract() error = #%v#, want #%v#", err, errDamaged)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil || string(got) != "old"{
+		t.Errorf("a.txt = #%q %v#, want #%q#", got, err, "old")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1{
+		t.Errorf("Extract() left %d entries, want 1", len(entries))
+	}
+}
+
+func Test_localLink(t* testing.T){
+	tests := []struct{
+		rel string
+		target string
+		want bool
+	}{
+		{rel: "link", target: "a.txt", want: true},
+		{rel: "sub/link", target: "../a.txt", want: true},
+		{rel: "sub/link", target: "../../a.txt", want: false},
+		{rel: "link", target: "/etc/passwd", want: false},
+		{rel: "link", target: "sub/../a.txt", want: false},
+		{rel: "link", target: "", want: false},
+		{rel: "link", target: ".", want: true},
+	}
+	for _, tt := range tests{
+		t.Run(tt.rel+" -> "+tt.target, func(t* testing.T){
+			if got := localLink(tt.rel, tt.target); got != tt.want{
+				t.Errorf("localLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
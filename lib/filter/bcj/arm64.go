@@ -0,0 +1,51 @@
+package bcj
+
+import "encoding/binary"
+
+const (
+	arm64InsnSize = 4
+
+	// BL: 100101 imm26
+	arm64BLOpcode = 0x25
+	arm64BLMask = 0x03FFFFFF
+)
+
+// ARM64 converts imm26 of BL instructions
+// from PC-relative word offsets to absolute word addresses.
+type ARM64 struct{}
+
+func NewARM64() ARM64{
+	return ARM64{}
+}
+
+func (f ARM64) Encode(data []byte){
+	arm64Convert(data, true)
+}
+
+func (f ARM64) Decode(data []byte){
+	arm64Convert(data, false)
+}
+
+// arm64Convert converts instructions in place.
+// Instructions are 4-byte aligned, opcode bits are never changed,
+// so every BL is converted and the transformation is always reversible.
+func arm64Convert(data []byte, encode bool){
+	for i := 0; i+arm64InsnSize <= len(data); i += arm64InsnSize{
+		insn := binary.LittleEndian.Uint32(data[i:])
+		if insn>>26 != arm64BLOpcode{
+			continue
+		}
+
+		pc := uint32(i) / arm64InsnSize
+		imm := insn & arm64BLMask
+
+		if encode{
+			imm += pc
+		}else{
+			imm -= pc
+		}
+
+		insn = arm64BLOpcode<<26 | imm&arm64BLMask
+		binary.LittleEndian.PutUint32(data[i:], insn)
+	}
+}
@@ -0,0 +1,89 @@
+package bcj
+
+import (
+	"testing"
+	"reflect"
+	"bytes"
+	"math/rand"
+	"encoding/binary"
+)
+
+func Test_ARM64_Encode(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			name: "bl forward",
+			// nop; bl +2
+			data: []byte{0x1F, 0x20, 0x03, 0xD5, 0x02, 0x00, 0x00, 0x94},
+			want: []byte{0x1F, 0x20, 0x03, 0xD5, 0x03, 0x00, 0x00, 0x94},
+		},
+		{
+			name: "bl backward",
+			// nop; bl -1
+			data: []byte{0x1F, 0x20, 0x03, 0xD5, 0xFF, 0xFF, 0xFF, 0x97},
+			want: []byte{0x1F, 0x20, 0x03, 0xD5, 0x00, 0x00, 0x00, 0x94},
+		},
+		{
+			name: "b is not converted",
+			data: []byte{0x1F, 0x20, 0x03, 0xD5, 0x02, 0x00, 0x00, 0x14},
+			want: []byte{0x1F, 0x20, 0x03, 0xD5, 0x02, 0x00, 0x00, 0x14},
+		},
+		{
+			name: "unaligned tail",
+			data: []byte{0x1F, 0x20, 0x03, 0xD5, 0x02, 0x00, 0x94},
+			want: []byte{0x1F, 0x20, 0x03, 0xD5, 0x02, 0x00, 0x94},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got := bytes.Clone(tt.data)
+			NewARM64().Encode(got)
+
+			if !reflect.DeepEqual(got, tt.want){
+				t.Errorf("Encode() = #%x#, want #%x#", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_ARM64_RoundTripExhaustive checks every instruction with a BL or B opcode
+// and boundary imm26 values at several positions.
+func Test_ARM64_RoundTripExhaustive(t* testing.T){
+	imms := []uint32{0, 1, 2, 0x01FFFFFF, 0x02000000, 0x03FFFFFE, 0x03FFFFFF}
+	opcodes := []uint32{arm64BLOpcode, 0x05, 0x24, 0x26}
+
+	f := NewARM64()
+	data := make([]byte, 64)
+
+	for pos := 0; pos+arm64InsnSize <= len(data); pos++{
+		for _, op := range opcodes{
+			for _, imm := range imms{
+				clear(data)
+				binary.LittleEndian.PutUint32(data[pos:], op<<26 | imm)
+
+				checkRoundTrip(t, f, data)
+			}
+		}
+	}
+}
+
+func Test_ARM64_RoundTripRandom(t* testing.T){
+	r := rand.New(rand.NewSource(1))
+	f := NewARM64()
+
+	for n := 0; n < 2000; n++{
+		data := make([]byte, r.Intn(4096))
+		r.Read(data)
+
+		for i := 0; i+arm64InsnSize <= len(data); i += arm64InsnSize{
+			if r.Intn(3) == 0{
+				binary.LittleEndian.PutUint32(data[i:], arm64BLOpcode<<26 | r.Uint32()&arm64BLMask)
+			}
+		}
+
+		checkRoundTrip(t, f, data)
+	}
+}
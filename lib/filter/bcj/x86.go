@@ -0,0 +1,73 @@
+package bcj
+
+import "encoding/binary"
+
+const (
+	x86Call = 0xE8
+	x86Jmp = 0xE9
+
+	// x86InsnSize is opcode + rel32
+	x86InsnSize = 5
+)
+
+// X86 converts rel32 operands of CALL (E8) and JMP (E9) instructions
+// to absolute addresses, so calls to the same function
+// become the same byte sequence and compress better.
+type X86 struct{}
+
+func NewX86() X86{
+	return X86{}
+}
+
+func (f X86) Encode(data []byte){
+	x86Convert(data, true)
+}
+
+func (f X86) Decode(data []byte){
+	x86Convert(data, false)
+}
+
+// x86Convert converts operands in place.
+// Only operands whose most significant byte is 0x00 or 0xFF are converted
+// (i.e. displacements within +-16MB), the result is stored with 25 significant bits
+// and sign extended, so the decoder recognizes converted operands by the same rule.
+//
+// If an opcode is left as is, the following 3 positions must not be converted either:
+// their operand would overwrite the byte the decoder checks for this opcode.
+func x86Convert(data []byte, encode bool){
+	blockedUntil := -1
+
+	for i := 0; i+x86InsnSize <= len(data); i++{
+		if data[i] != x86Call && data[i] != x86Jmp{
+			continue
+		}
+
+		if i <= blockedUntil || !isDisplacementMSByte(data[i+4]){
+			blockedUntil = i + x86InsnSize - 2
+			continue
+		}
+
+		src := binary.LittleEndian.Uint32(data[i+1:])
+		pos := uint32(i + x86InsnSize)
+
+		var dst uint32
+		if encode{
+			dst = src + pos
+		}else{
+			dst = src - pos
+		}
+
+		// keep 25 bits and restore 0x00/0xFF in the most significant byte
+		dst &= 0x01FFFFFF
+		if dst&0x01000000 != 0{
+			dst |= 0xFF000000
+		}
+
+		binary.LittleEndian.PutUint32(data[i+1:], dst)
+		i += x86InsnSize - 1
+	}
+}
+
+func isDisplacementMSByte(b byte) bool{
+	return b == 0x00 || b == 0xFF
+}
@@ -0,0 +1,148 @@
+package bcj
+
+import (
+	"testing"
+	"reflect"
+	"bytes"
+	"math/rand"
+	"encoding/binary"
+)
+
+func Test_X86_Encode(t* testing.T){
+	tests := []struct{
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			name: "call forward",
+			data: []byte{0xE8, 0x0B, 0x00, 0x00, 0x00},
+			want: []byte{0xE8, 0x10, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "jmp backward",
+			data: []byte{0x90, 0xE9, 0xF6, 0xFF, 0xFF, 0xFF},
+			want: []byte{0x90, 0xE9, 0xFC, 0xFF, 0xFF, 0xFF},
+		},
+		{
+			name: "not a displacement",
+			data: []byte{0xE8, 0x12, 0x34, 0x56, 0x78},
+			want: []byte{0xE8, 0x12, 0x34, 0x56, 0x78},
+		},
+		{
+			name: "call right after unconverted opcode",
+			data: []byte{0xE8, 0xE8, 0x01, 0x00, 0x12, 0x00},
+			want: []byte{0xE8, 0xE8, 0x01, 0x00, 0x12, 0x00},
+		},
+		{
+			name: "too short",
+			data: []byte{0xE8, 0x01, 0x00, 0x00},
+			want: []byte{0xE8, 0x01, 0x00, 0x00},
+		},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			got := bytes.Clone(tt.data)
+			NewX86().Encode(got)
+
+			if !reflect.DeepEqual(got, tt.want){
+				t.Errorf("Encode() = #%x#, want #%x#", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_X86_SameTarget(t* testing.T){
+	const target = 0x4000
+
+	var code []byte
+	for pos := 0x100; pos < 0x200; pos += 0x10{
+		code = append(code, bytes.Repeat([]byte{0x90}, 0x10 - x86InsnSize)...)
+
+		rel := make([]byte, 4)
+		binary.LittleEndian.PutUint32(rel, uint32(target - (len(code) + x86InsnSize)))
+		code = append(code, x86Call)
+		code = append(code, rel...)
+	}
+
+	NewX86().Encode(code)
+
+	want := []byte{x86Call, 0x00, 0x40, 0x00, 0x00}
+	for i := 0x10 - x86InsnSize; i < len(code); i += 0x10{
+		if got := code[i:i+x86InsnSize]; !reflect.DeepEqual(got, want){
+			t.Errorf("call at %d = #%x#, want #%x#", i, got, want)
+		}
+	}
+}
+
+// Test_X86_RoundTripExhaustive checks every buffer up to 7 bytes long
+// built from opcodes and interesting operand bytes.
+func Test_X86_RoundTripExhaustive(t* testing.T){
+	alphabet := []byte{0x00, 0x01, 0x80, x86Call, x86Jmp, 0xFF}
+	const maxLen = 7
+
+	f := NewX86()
+
+	for size := 0; size <= maxLen; size++{
+		data := make([]byte, size)
+		idx := make([]int, size)
+
+		for{
+			for i := range data{
+				data[i] = alphabet[idx[i]]
+			}
+
+			checkRoundTrip(t, f, data)
+
+			i := 0
+			for ; i < size; i++{
+				idx[i]++
+				if idx[i] < len(alphabet){
+					break
+				}
+				idx[i] = 0
+			}
+			if i == size{
+				break
+			}
+		}
+	}
+}
+
+func Test_X86_RoundTripRandom(t* testing.T){
+	r := rand.New(rand.NewSource(1))
+	f := NewX86()
+
+	for n := 0; n < 2000; n++{
+		data := syntheticX86(r, r.Intn(4096))
+		checkRoundTrip(t, f, data)
+	}
+}
+
+// syntheticX86 generates random bytes with lots of CALL/JMP opcodes
+// and small displacements.
+func syntheticX86(r *rand.Rand, size int) []byte{
+	data := make([]byte, size)
+	r.Read(data)
+
+	for i := 0; i+x86InsnSize <= size; i += r.Intn(8) + 1{
+		data[i] = []byte{x86Call, x86Jmp}[r.Intn(2)]
+		if r.Intn(4) != 0{
+			binary.LittleEndian.PutUint32(data[i+1:], uint32(r.Intn(1<<25) - 1<<24))
+		}
+	}
+
+	return data
+}
+
+func checkRoundTrip(t* testing.T, f interface{ Encode([]byte); Decode([]byte) }, data []byte){
+	t.Helper()
+
+	got := bytes.Clone(data)
+	f.Encode(got)
+	f.Decode(got)
+
+	if !bytes.Equal(got, data){
+		t.Fatalf("round trip of #%x# = #%x#", data, got)
+	}
+}
@@ -0,0 +1,9 @@
+package filter
+
+// Filter is a reversible transformation applied to raw data
+// before it is passed to an encoder and after it is decoded.
+// Both methods work in place.
+type Filter interface{
+	Encode(data []byte)
+	Decode(data []byte)
+}
@@ -0,0 +1,96 @@
+package recovery
+
+import "errors"
+
+// gfPoly is the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1 of GF(2^8)
+const gfPoly = 0x11d
+
+var errSingular = errors.New("singular matrix")
+
+// gfExp and gfLog are powers of the generator 2 and their logarithms,
+// gfExp is doubled so products of logarithms need no modulo
+var gfExp [510]byte
+var gfLog [256]int
+
+func init(){
+	x := 1
+	for i := 0; i < 255; i++{
+		gfExp[i] = byte(x)
+		gfExp[i+255] = byte(x)
+		gfLog[x] = i
+
+		x <<= 1
+		if x & 0x100 != 0{
+			x ^= gfPoly
+		}
+	}
+}
+
+func gfMul(a, b byte) byte{
+	if a == 0 || b == 0{
+		return 0
+	}
+
+	return gfExp[gfLog[a] + gfLog[b]]
+}
+
+// gfInv returns 1/a, a must not be 0
+func gfInv(a byte) byte{
+	return gfExp[255 - gfLog[a]]
+}
+
+// gfMulAdd adds c*src to dst, addition in GF(2^8) is xor
+func gfMulAdd(dst, src []byte, c byte){
+	if c == 0{
+		return
+	}
+
+	logC := gfLog[c]
+	for i, b := range src{
+		if b != 0{
+			dst[i] ^= gfExp[gfLog[b] + logC]
+		}
+	}
+}
+
+// gfInvert inverts square matrix m by Gauss-Jordan elimination
+func gfInvert(m [][]byte) ([][]byte, error){
+	n := len(m)
+
+	// m | identity, m is reduced to identity and identity becomes the inverse
+	work := make([][]byte, n)
+	for i := range m{
+		work[i] = make([]byte, 2*n)
+		copy(work[i], m[i])
+		work[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++{
+		pivot := col
+		for pivot < n && work[pivot][col] == 0{
+			pivot++
+		}
+		if pivot == n{
+			return nil, errSingular
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+
+		inv := gfInv(work[col][col])
+		for j := range work[col]{
+			work[col][j] = gfMul(work[col][j], inv)
+		}
+
+		for row := 0; row < n; row++{
+			if row != col && work[row][col] != 0{
+				gfMulAdd(work[row], work[col], work[row][col])
+			}
+		}
+	}
+
+	res := make([][]byte, n)
+	for i := range work{
+		res[i] = work[i][n:]
+	}
+
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package recovery
+
+import (
+	"testing"
+	"errors"
+	"reflect"
+)
+
+func Test_gfInv(t* testing.T){
+	for a := 1; a < 256; a++{
+		if got := gfMul(byte(a), gfInv(byte(a))); got != 1{
+			t.Errorf("%d * gfInv(%d) = %d, want 1", a, a, got)
+		}
+	}
+}
+
+func Test_gfInvert(t* testing.T){
+	tests := []struct{
+		name string
+		m [][]byte
+		wantErr error
+	}{
+		{name: "identity", m: [][]byte{{1, 0}, {0, 1}}},
+		{name: "cauchy", m: [][]byte{{coef(0, 0, 3), coef(0, 1, 3), coef(0, 2, 3)}, {0, 1, 0}, {coef(1, 0, 3), coef(1, 1, 3), coef(1, 2, 3)}}},
+		{name: "singular", m: [][]byte{{1, 2}, {1, 2}}, wantErr: errSingular},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			inv, err := gfInvert(tt.m)
+			if !errors.Is(err, tt.wantErr){
+				t.Fatalf("gfInvert() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+			if err != nil{
+				return
+			}
+
+			// m * inv is identity
+			n := len(tt.m)
+			got := make([][]byte, n)
+			want := make([][]byte, n)
+			for i := range got{
+				got[i] = make([]byte, n)
+				want[i] = make([]byte, n)
+				want[i][i] = 1
+				for j := 0; j < n; j++{
+					for l := 0; l < n; l++{
+						got[i][j] ^= gfMul(tt.m[i][l], inv[l][j])
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(got, want){
+				t.Errorf("m * gfInvert(m) = #%v#, want #%v#", got, want)
+			}
+		})
+	}
+}
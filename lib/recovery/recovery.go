@@ -0,0 +1,327 @@
+package recovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"archiver/lib/trailer"
+)
+
+const (
+	// groupShards is the largest number of data shards of a group,
+	// with at most as many parity shards a group fits into GF(2^8)
+	groupShards = 128
+	minShardSize = 64
+	maxShardSize = 512
+)
+
+// headerSize is shard size | content size | percent
+const headerSize = 4 + 8 + 1
+
+var ErrInvalidPercent = errors.New("recovery percent must be 1-100")
+var ErrNoRecord = errors.New("file has no recovery record")
+var ErrInvalidRecord = errors.New("invalid recovery record")
+var ErrTooDamaged = errors.New("file is too damaged to repair")
+
+// record is parity data of content split into shards of shardSize bytes,
+// the last shard is padded with zeros. Shard i belongs to group i % groups,
+// so damage of adjacent shards is spread over groups.
+type record struct{
+	shardSize int
+	contentSize int64
+	percent int
+	checksums []uint32
+	parityChecksums []uint32
+	// parity shards of groups in order
+	parity [][]byte
+	// damagedIndex is the number of damaged copies of the index
+	damagedIndex int
+}
+
+// Protect returns the recovery trailer of content with parity of about percent of its size:
+// index | parity shards | index, the index is
+// header | checksums of data shards | checksums of parity shards | checksum of all of them.
+// The index is written twice, the record can't be used only if both copies are damaged.
+func Protect(content []byte, percent int) (trailer.Trailer, error){
+	if percent < 1 || percent > 100{
+		return trailer.Trailer{}, fmt.Errorf("%w: %d", ErrInvalidPercent, percent)
+	}
+
+	rec := record{
+		shardSize: shardSize(int64(len(content))),
+		contentSize: int64(len(content)),
+		percent: percent,
+	}
+
+	shards := rec.split(content)
+	for _, shard := range shards{
+		rec.checksums = append(rec.checksums, crc32.ChecksumIEEE(shard))
+	}
+
+	for _, group := range rec.groups(){
+		data := make([][]byte, len(group))
+		for j, i := range group{
+			data[j] = shards[i]
+		}
+
+		for _, p := range encodeGroup(data, parityShards(len(group), percent)){
+			rec.parity = append(rec.parity, p)
+			rec.parityChecksums = append(rec.parityChecksums, crc32.ChecksumIEEE(p))
+		}
+	}
+
+	return trailer.Trailer{Type: trailer.Recovery, Data: rec.encode()}, nil
+}
+
+// Check returns the number of damaged blocks of content and of its recovery record
+func Check(content []byte, trailers []trailer.Trailer) (int, error){
+	rec, err := findRecord(content, trailers)
+	if err != nil{
+		return 0, err
+	}
+
+	shards := rec.split(content)
+	damaged := 0
+
+	for i, shard := range shards{
+		if crc32.ChecksumIEEE(shard) != rec.checksums[i]{
+			damaged++
+		}
+	}
+	for i, p := range rec.parity{
+		if crc32.ChecksumIEEE(p) != rec.parityChecksums[i]{
+			damaged++
+		}
+	}
+
+	return damaged + rec.damagedIndex, nil
+}
+
+// Repair reconstructs damaged blocks of content and of its recovery record,
+// it returns repaired content, the repaired recovery trailer and the number of repaired blocks
+func Repair(content []byte, trailers []trailer.Trailer) ([]byte, trailer.Trailer, int, error){
+	rec, err := findRecord(content, trailers)
+	if err != nil{
+		return nil, trailer.Trailer{}, 0, err
+	}
+
+	shards := rec.split(content)
+	// the damaged index copy is rewritten
+	repaired := rec.damagedIndex
+	parityStart := 0
+
+	for g, group := range rec.groups(){
+		k := len(group)
+		m := parityShards(k, rec.percent)
+
+		members := make([][]byte, 0, k+m)
+		ok := make([]bool, 0, k+m)
+		damaged := 0
+
+		for _, i := range group{
+			members = append(members, shards[i])
+			ok = append(ok, crc32.ChecksumIEEE(shards[i]) == rec.checksums[i])
+		}
+		for i := parityStart; i < parityStart+m; i++{
+			members = append(members, rec.parity[i])
+			ok = append(ok, crc32.ChecksumIEEE(rec.parity[i]) == rec.parityChecksums[i])
+		}
+		for _, shardOK := range ok{
+			if !shardOK{
+				damaged++
+			}
+		}
+
+		if damaged > 0{
+			if err := reconstructGroup(members, ok, k); err != nil{
+				return nil, trailer.Trailer{}, 0, fmt.Errorf("%w: %d of %d blocks of group %d are damaged", err, damaged, k+m, g)
+			}
+
+			for j, i := range group{
+				shards[i] = members[j]
+			}
+			copy(rec.parity[parityStart:], members[k:])
+			repaired += damaged
+		}
+
+		parityStart += m
+	}
+
+	res := make([]byte, 0, rec.contentSize)
+	for _, shard := range shards{
+		res = append(res, shard...)
+	}
+	res = res[:rec.contentSize]
+
+	return res, trailer.Trailer{Type: trailer.Recovery, Data: rec.encode()}, repaired, nil
+}
+
+// findRecord returns the last recovery record of trailers protecting content
+func findRecord(content []byte, trailers []trailer.Trailer) (record, error){
+	for i := len(trailers)-1; i >= 0; i--{
+		if trailers[i].Type != trailer.Recovery{
+			continue
+		}
+
+		return decodeRecord(trailers[i].Data, int64(len(content)))
+	}
+
+	return record{}, ErrNoRecord
+}
+
+// shardSize returns size of shards of content,
+// small contents are split into a group of small shards
+func shardSize(contentSize int64) int{
+	size := (contentSize + groupShards - 1) / groupShards
+
+	return int(min(max(size, minShardSize), maxShardSize))
+}
+
+// parityShards returns the number of parity shards of a group of k data shards
+func parityShards(k, percent int) int{
+	return max((k*percent + 99) / 100, 1)
+}
+
+// split returns content split into shards,
+// the last shard is copied and padded with zeros
+func (rec record) split(content []byte) [][]byte{
+	var shards [][]byte
+
+	for start := 0; start < len(content); start += rec.shardSize{
+		end := start + rec.shardSize
+		if end <= len(content){
+			shards = append(shards, content[start:end:end])
+			continue
+		}
+
+		last := make([]byte, rec.shardSize)
+		copy(last, content[start:])
+		shards = append(shards, last)
+	}
+
+	return shards
+}
+
+func (rec record) shardsCount() int{
+	return int((rec.contentSize + int64(rec.shardSize) - 1) / int64(rec.shardSize))
+}
+
+// groups returns indexes of data shards of every group
+func (rec record) groups() [][]int{
+	n := rec.shardsCount()
+	count := (n + groupShards - 1) / groupShards
+
+	groups := make([][]int, count)
+	for i := 0; i < n; i++{
+		groups[i%count] = append(groups[i%count], i)
+	}
+
+	return groups
+}
+
+func (rec record) encode() []byte{
+	index := rec.encodeIndex()
+
+	res := make([]byte, 0, 2*len(index) + len(rec.parity)*rec.shardSize)
+	res = append(res, index...)
+	for _, p := range rec.parity{
+		res = append(res, p...)
+	}
+
+	return append(res, index...)
+}
+
+// encodeIndex returns header | checksums of data shards | checksums of parity shards | checksum of all of them
+func (rec record) encodeIndex() []byte{
+	res := make([]byte, 0, indexSize(len(rec.checksums) + len(rec.parityChecksums)))
+
+	res = binary.BigEndian.AppendUint32(res, uint32(rec.shardSize))
+	res = binary.BigEndian.AppendUint64(res, uint64(rec.contentSize))
+	res = append(res, byte(rec.percent))
+
+	for _, c := range rec.checksums{
+		res = binary.BigEndian.AppendUint32(res, c)
+	}
+	for _, c := range rec.parityChecksums{
+		res = binary.BigEndian.AppendUint32(res, c)
+	}
+
+	return binary.BigEndian.AppendUint32(res, crc32.ChecksumIEEE(res))
+}
+
+// indexSize returns the size of the index of n checksums
+func indexSize(n int) int{
+	return headerSize + 4*n + 4
+}
+
+// decodeRecord decodes the record of content of contentSize bytes,
+// the layout is known from the sizes, so the index copy at the end
+// is used when the first one is damaged
+func decodeRecord(data []byte, contentSize int64) (record, error){
+	rec := record{shardSize: shardSize(contentSize), contentSize: contentSize}
+	n := rec.shardsCount()
+
+	// size is 2 indexes of n + parityCount checksums and parityCount shards
+	rest := int64(len(data)) - 2*int64(indexSize(n))
+	if rest < 0 || rest % int64(8 + rec.shardSize) != 0{
+		return rec, fmt.Errorf("%w: record size %d for %d bytes", ErrInvalidRecord, len(data), contentSize)
+	}
+	parityCount := int(rest / int64(8 + rec.shardSize))
+	size := indexSize(n + parityCount)
+
+	copies := [][]byte{data[:size], data[len(data)-size:]}
+	for i, index := range copies{
+		if !rec.validIndex(index, parityCount){
+			continue
+		}
+		rec.damagedIndex = i
+
+		rec.percent = int(index[12])
+		for j := 0; j < n + parityCount; j++{
+			c := binary.BigEndian.Uint32(index[headerSize + 4*j:])
+			if j < n{
+				rec.checksums = append(rec.checksums, c)
+			}else{
+				rec.parityChecksums = append(rec.parityChecksums, c)
+			}
+		}
+
+		// the copy at the end is damaged too if it differs
+		if i == 0 && string(copies[1]) != string(index){
+			rec.damagedIndex = 1
+		}
+
+		parity := data[size:len(data)-size]
+		for j := 0; j < parityCount; j++{
+			rec.parity = append(rec.parity, append([]byte(nil), parity[j*rec.shardSize:(j+1)*rec.shardSize]...))
+		}
+
+		return rec, nil
+	}
+
+	return rec, fmt.Errorf("%w: both copies of checksums are damaged or don't match content", ErrInvalidRecord)
+}
+
+// validIndex reports whether index isn't damaged and describes the record of rec content
+// with parityCount parity shards
+func (rec record) validIndex(index []byte, parityCount int) bool{
+	checksum := len(index) - 4
+	if crc32.ChecksumIEEE(index[:checksum]) != binary.BigEndian.Uint32(index[checksum:]){
+		return false
+	}
+
+	percent := int(index[12])
+	if int(binary.BigEndian.Uint32(index)) != rec.shardSize || int64(binary.BigEndian.Uint64(index[4:])) != rec.contentSize ||
+		percent < 1 || percent > 100{
+		return false
+	}
+
+	count := 0
+	for _, group := range rec.groups(){
+		count += parityShards(len(group), percent)
+	}
+
+	return count == parityCount
+}
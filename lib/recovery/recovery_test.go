@@ -0,0 +1,214 @@
+package recovery
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"math/rand"
+
+	"archiver/lib/trailer"
+)
+
+func randomContent(r *rand.Rand, size int) []byte{
+	content := make([]byte, size)
+	r.Read(content)
+
+	return content
+}
+
+func TestRepair(t* testing.T){
+	tests := []struct{
+		name string
+		size int
+		percent int
+		// damaged bytes of content
+		damage int
+		// damaged bytes of the recovery record
+		recordDamage int
+		// size of damaged run of content bytes
+		burst int
+	}{
+		{name: "no damage", size: 10000, percent: 5},
+		{name: "small file", size: 100, percent: 5, damage: 1},
+		{name: "random bytes", size: 300000, percent: 5, damage: 12},
+		{name: "damaged parity", size: 300000, percent: 5, damage: 8, recordDamage: 5},
+		{name: "large parity", size: 50000, percent: 50, damage: 40},
+		{name: "last block", size: 12345, percent: 10, damage: 3},
+		{name: "burst", size: 300000, percent: 5, burst: 8000},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			r := rand.New(rand.NewSource(int64(tt.size)))
+			content := randomContent(r, tt.size)
+
+			rec, err := Protect(content, tt.percent)
+			if err != nil{
+				t.Fatalf("Protect() error = %v", err)
+			}
+
+			damaged := append([]byte(nil), content...)
+			for i := 0; i < tt.damage; i++{
+				damaged[r.Intn(len(damaged))] ^= byte(1 + r.Intn(255))
+			}
+			start := r.Intn(len(damaged) - tt.burst + 1)
+			for i := start; i < start + tt.burst; i++{
+				damaged[i] ^= 0xFF
+			}
+
+			damagedRec := trailer.Trailer{Type: rec.Type, Data: append([]byte(nil), rec.Data...)}
+			for i := 0; i < tt.recordDamage; i++{
+				// the parity is at the end of the record
+				pos := len(damagedRec.Data) - 1 - r.Intn(len(damagedRec.Data)/2)
+				damagedRec.Data[pos] ^= byte(1 + r.Intn(255))
+			}
+
+			trailers := []trailer.Trailer{damagedRec}
+
+			if _, err := Check(damaged, trailers); err != nil{
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			got, repairedRec, _, err := Repair(damaged, trailers)
+			if err != nil{
+				t.Fatalf("Repair() error = %v", err)
+			}
+			if !bytes.Equal(got, content){
+				t.Errorf("Repair() content differs from the original")
+			}
+			if !bytes.Equal(repairedRec.Data, rec.Data){
+				t.Errorf("Repair() record differs from the original")
+			}
+
+			if n, err := Check(got, []trailer.Trailer{repairedRec}); err != nil || n != 0{
+				t.Errorf("Check() after repair = #%d %v#, want #0#", n, err)
+			}
+		})
+	}
+}
+
+func TestRepair_Errors(t* testing.T){
+	content := randomContent(rand.New(rand.NewSource(1)), 100000)
+	rec, _ := Protect(content, 5)
+
+	// every byte of the first blocks is damaged
+	tooDamaged := append([]byte(nil), content...)
+	for i := 0; i < len(tooDamaged)/4; i++{
+		tooDamaged[i] ^= 0xFF
+	}
+
+	// both copies of the checksums are damaged
+	badRecord := trailer.Trailer{Type: rec.Type, Data: append([]byte(nil), rec.Data...)}
+	badRecord.Data[headerSize] ^= 1
+	badRecord.Data[len(badRecord.Data)-5] ^= 1
+
+	tests := []struct{
+		name string
+		content []byte
+		trailers []trailer.Trailer
+		wantErr error
+	}{
+		{name: "no record", content: content, trailers: []trailer.Trailer{{Type: trailer.Signature}}, wantErr: ErrNoRecord},
+		{name: "too damaged", content: tooDamaged, trailers: []trailer.Trailer{rec}, wantErr: ErrTooDamaged},
+		{name: "truncated content", content: content[:len(content)-1], trailers: []trailer.Trailer{rec}, wantErr: ErrInvalidRecord},
+		{name: "damaged checksums", content: content, trailers: []trailer.Trailer{badRecord}, wantErr: ErrInvalidRecord},
+		{name: "empty record", content: content, trailers: []trailer.Trailer{{Type: trailer.Recovery}}, wantErr: ErrInvalidRecord},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, _, _, err := Repair(tt.content, tt.trailers); !errors.Is(err, tt.wantErr){
+				t.Errorf("Repair() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRepair_DamagedIndex(t* testing.T){
+	content := randomContent(rand.New(rand.NewSource(2)), 100000)
+	rec, _ := Protect(content, 5)
+
+	tests := []struct{
+		name string
+		// offset of the damaged byte from the start of the record, negative from the end
+		pos int
+	}{
+		{name: "header", pos: 0},
+		{name: "first checksums", pos: headerSize + 10},
+		{name: "checksums at the end", pos: -100},
+		{name: "checksum of checksums at the end", pos: -1},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			damaged := trailer.Trailer{Type: rec.Type, Data: append([]byte(nil), rec.Data...)}
+			pos := tt.pos
+			if pos < 0{
+				pos += len(damaged.Data)
+			}
+			damaged.Data[pos] ^= 0xFF
+			trailers := []trailer.Trailer{damaged}
+
+			if n, err := Check(content, trailers); err != nil || n != 1{
+				t.Errorf("Check() = #%d %v#, want #1#", n, err)
+			}
+
+			got, repairedRec, count, err := Repair(content, trailers)
+			if err != nil || count != 1{
+				t.Fatalf("Repair() = #%d %v#, want #1#", count, err)
+			}
+			if !bytes.Equal(got, content) || !bytes.Equal(repairedRec.Data, rec.Data){
+				t.Errorf("Repair() content or record differs from the original")
+			}
+		})
+	}
+}
+
+func TestProtect_Percent(t* testing.T){
+	for _, percent := range []int{0, 101, -5}{
+		if _, err := Protect([]byte("content"), percent); !errors.Is(err, ErrInvalidPercent){
+			t.Errorf("Protect(%d) error = #%v#, want #%v#", percent, err, ErrInvalidPercent)
+		}
+	}
+}
+
+func Test_reconstructGroup(t* testing.T){
+	r := rand.New(rand.NewSource(2))
+
+	const k, m = 10, 4
+
+	data := make([][]byte, k)
+	for i := range data{
+		data[i] = randomContent(r, 16)
+	}
+	want := append(append([][]byte(nil), data...), encodeGroup(data, m)...)
+
+	// a seventh of combinations of up to m lost data and parity shards
+	for mask := 0; mask < 1<<(k+m); mask++{
+		lost := 0
+		for i := 0; i < k+m; i++{
+			if mask & (1<<i) != 0{
+				lost++
+			}
+		}
+		if lost > m || lost == 0 || mask % 7 != 0{
+			continue
+		}
+
+		shards := make([][]byte, k+m)
+		ok := make([]bool, k+m)
+		for i := range shards{
+			ok[i] = mask & (1<<i) == 0
+			shards[i] = make([]byte, 16)
+			if ok[i]{
+				copy(shards[i], want[i])
+			}
+		}
+
+		if err := reconstructGroup(shards, ok, k); err != nil{
+			t.Fatalf("reconstructGroup(%b) error = %v", mask, err)
+		}
+		for i := range shards{
+			if !bytes.Equal(shards[i], want[i]){
+				t.Fatalf("reconstructGroup(%b) shard %d differs", mask, i)
+			}
+		}
+	}
+}
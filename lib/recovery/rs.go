@@ -0,0 +1,86 @@
+package recovery
+
+// Reed-Solomon erasure code over GF(2^8) with a systematic Cauchy matrix:
+// data shards are kept as they are, parity shard i of a group of k data shards
+// is the sum of coef(i, j) * data shard j. Any k of data and parity shards
+// give an invertible matrix, so up to the number of parity shards
+// lost shards of a group are reconstructed.
+
+// coef returns coefficient of data shard j in parity shard i,
+// 1/(x_i + y_j) with x_i = k+i and y_j = j distinct while k+m <= 256
+func coef(i, j, k int) byte{
+	return gfInv(byte(k+i) ^ byte(j))
+}
+
+// encodeGroup returns m parity shards of data shards of equal size
+func encodeGroup(data [][]byte, m int) [][]byte{
+	parity := make([][]byte, m)
+
+	for i := range parity{
+		parity[i] = make([]byte, len(data[0]))
+		for j, shard := range data{
+			gfMulAdd(parity[i], shard, coef(i, j, len(data)))
+		}
+	}
+
+	return parity
+}
+
+// reconstructGroup rebuilds shards which aren't ok in place,
+// shards are k data shards followed by parity shards
+func reconstructGroup(shards [][]byte, ok []bool, k int) error{
+	// the first k intact shards
+	rows := make([]int, 0, k)
+	for i := range shards{
+		if ok[i]{
+			rows = append(rows, i)
+		}
+		if len(rows) == k{
+			break
+		}
+	}
+	if len(rows) < k{
+		return ErrTooDamaged
+	}
+
+	// rows of the encoding matrix of the intact shards
+	m := make([][]byte, k)
+	for t, r := range rows{
+		m[t] = make([]byte, k)
+		if r < k{
+			m[t][r] = 1
+			continue
+		}
+		for j := range m[t]{
+			m[t][j] = coef(r-k, j, k)
+		}
+	}
+
+	inv, err := gfInvert(m)
+	if err != nil{
+		return err
+	}
+
+	size := len(shards[rows[0]])
+
+	for j := 0; j < k; j++{
+		if ok[j]{
+			continue
+		}
+
+		shard := make([]byte, size)
+		for t, r := range rows{
+			gfMulAdd(shard, shards[r], inv[j][t])
+		}
+		shards[j] = shard
+	}
+
+	parity := encodeGroup(shards[:k], len(shards)-k)
+	for i := range parity{
+		if !ok[k+i]{
+			shards[k+i] = parity[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package sign
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"archiver/lib/compression"
+	"archiver/lib/trailer"
+)
+
+// signatureContext separates archive signatures from other uses of the keys
+const signatureContext = "archiver signature v1\x00"
+
+// key text prefixes
+const (
+	publicPrefix = "archiver-ed25519:"
+	secretPrefix = "ARCHIVER-ED25519-SECRET:"
+)
+
+var ErrInvalidKey = errors.New("invalid key")
+var ErrNoSignature = errors.New("file isn't signed by the key")
+var ErrBadSignature = errors.New("signature doesn't match the file")
+
+// GenerateKey returns a new key pair
+func GenerateKey() (ed25519.PublicKey, ed25519.PrivateKey, error){
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// Sign returns the signature trailer of content:
+// public key | signature of the header and payload hashes.
+// The header is the method or encryption header, the payload is the rest of content.
+func Sign(content []byte, key ed25519.PrivateKey) trailer.Trailer{
+	pub := key.Public().(ed25519.PublicKey)
+	sig := ed25519.Sign(key, message(content))
+
+	return trailer.Trailer{Type: trailer.Signature, Data: append(append([]byte(nil), pub...), sig...)}
+}
+
+// Verify checks that one of the signature trailers is made by key
+func Verify(content []byte, trailers []trailer.Trailer, key ed25519.PublicKey) error{
+	msg := message(content)
+	found := false
+
+	for _, t := range trailers{
+		if t.Type != trailer.Signature || len(t.Data) != ed25519.PublicKeySize + ed25519.SignatureSize{
+			continue
+		}
+
+		if !bytes.Equal(t.Data[:ed25519.PublicKeySize], key){
+			continue
+		}
+		found = true
+
+		if ed25519.Verify(key, msg, t.Data[ed25519.PublicKeySize:]){
+			return nil
+		}
+	}
+
+	if found{
+		return ErrBadSignature
+	}
+
+	return ErrNoSignature
+}
+
+func message(content []byte) []byte{
+	headerSize := min(compression.HeaderSize, len(content))
+	header := sha256.Sum256(content[:headerSize])
+	payload := sha256.Sum256(content[headerSize:])
+
+	msg := append([]byte(signatureContext), header[:]...)
+
+	return append(msg, payload[:]...)
+}
+
+
+func FormatPublicKey(key ed25519.PublicKey) string{
+	return publicPrefix + base64.RawURLEncoding.EncodeToString(key)
+}
+
+// FormatPrivateKey returns the seed of the key
+func FormatPrivateKey(key ed25519.PrivateKey) string{
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(key.Seed())
+}
+
+// ReadPublicKey reads the first key of the key file,
+// empty lines and lines starting with # are skipped
+func ReadPublicKey(r io.Reader) (ed25519.PublicKey, error){
+	data, err := readKey(r, publicPrefix, ed25519.PublicKeySize)
+	if err != nil{
+		return nil, err
+	}
+
+	return ed25519.PublicKey(data), nil
+}
+
+// ReadPrivateKey reads the first key of the key file,
+// empty lines and lines starting with # are skipped
+func ReadPrivateKey(r io.Reader) (ed25519.PrivateKey, error){
+	data, err := readKey(r, secretPrefix, ed25519.SeedSize)
+	if err != nil{
+		return nil, err
+	}
+
+	return ed25519.NewKeyFromSeed(data), nil
+}
+
+func readKey(r io.Reader, prefix string, size int) ([]byte, error){
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan(){
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#"){
+			continue
+		}
+
+		if !strings.HasPrefix(line, prefix){
+			return nil, fmt.Errorf("%w: want %s prefix", ErrInvalidKey, prefix)
+		}
+
+		data, err := base64.RawURLEncoding.DecodeString(line[len(prefix):])
+		if err != nil{
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		if len(data) != size{
+			return nil, fmt.Errorf("%w: key size %d, want %d", ErrInvalidKey, len(data), size)
+		}
+
+		return data, nil
+	}
+
+	if err := scanner.Err(); err != nil{
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("%w: no key", ErrInvalidKey)
+}
@@ -0,0 +1,67 @@
+package sign
+
+import (
+	"testing"
+	"errors"
+	"strings"
+
+	"archiver/lib/trailer"
+)
+
+func TestVerify(t* testing.T){
+	pub, priv, err := GenerateKey()
+	if err != nil{
+		t.Fatal(err)
+	}
+	otherPub, otherPriv, _ := GenerateKey()
+
+	content := []byte("GOAR\x02packed payload")
+	sig := Sign(content, priv)
+
+	altered := append([]byte(nil), content...)
+	altered[len(altered)-1]++
+
+	tests := []struct{
+		name string
+		content []byte
+		trailers []trailer.Trailer
+		wantErr error
+	}{
+		{name: "signed", content: content, trailers: []trailer.Trailer{sig}},
+		{name: "signed twice", content: content, trailers: []trailer.Trailer{Sign(content, otherPriv), sig}},
+		{name: "altered payload", content: altered, trailers: []trailer.Trailer{sig}, wantErr: ErrBadSignature},
+		{name: "other key", content: content, trailers: []trailer.Trailer{Sign(content, otherPriv)}, wantErr: ErrNoSignature},
+		{name: "not signed", content: content, wantErr: ErrNoSignature},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if err := Verify(tt.content, tt.trailers, pub); !errors.Is(err, tt.wantErr){
+				t.Errorf("Verify() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := Verify(content, []trailer.Trailer{Sign(content, otherPriv)}, otherPub); err != nil{
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestReadKey(t* testing.T){
+	pub, priv, _ := GenerateKey()
+
+	gotPub, err := ReadPublicKey(strings.NewReader("# build system\n" + FormatPublicKey(pub) + "\n"))
+	if err != nil || !gotPub.Equal(pub){
+		t.Errorf("ReadPublicKey() = #%v %v#, want #%v#", gotPub, err, pub)
+	}
+
+	gotPriv, err := ReadPrivateKey(strings.NewReader(FormatPrivateKey(priv)))
+	if err != nil || !gotPriv.Equal(priv){
+		t.Errorf("ReadPrivateKey() error = %v", err)
+	}
+
+	for _, key := range []string{"", FormatPrivateKey(priv), publicPrefix + "AAAA"}{
+		if _, err := ReadPublicKey(strings.NewReader(key)); !errors.Is(err, ErrInvalidKey){
+			t.Errorf("ReadPublicKey(%q) error = #%v#, want #%v#", key, err, ErrInvalidKey)
+		}
+	}
+}
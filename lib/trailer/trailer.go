@@ -0,0 +1,190 @@
+package trailer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// footerMagic ends files with trailers
+const footerMagic = "GOAT"
+
+// footerSize is trailers size | trailers checksum | footer checksum | magic,
+// the footer checksum tells trailers from content which happens to end with the magic
+const footerSize = 4 + 4 + 4 + len(footerMagic)
+
+// footersSize is the size of two copies of the footer ending files with trailers,
+// trailers are found by the other copy if one is damaged
+const footersSize = 2 * footerSize
+
+// trailer entry is type | data size | data
+const entryHeaderSize = 1 + 4
+
+// trailer types
+const (
+	Signature byte = iota + 1
+	Recovery
+)
+
+var ErrInvalidTrailers = errors.New("invalid trailers")
+var ErrDamagedFooter = errors.New("damaged footer")
+
+// Trailer is data appended after the packed content, e.g. a signature
+type Trailer struct{
+	Type byte
+	Data []byte
+}
+
+// Append returns content followed by its trailers and the new ones,
+// trailers already appended to content are kept
+func Append(data []byte, trailers ...Trailer) ([]byte, error){
+	content, existing, err := Split(data)
+	if err != nil{
+		return nil, err
+	}
+
+	trailers = append(existing, trailers...)
+
+	res := append([]byte(nil), content...)
+	start := len(res)
+
+	for _, t := range trailers{
+		res = append(res, t.Type)
+		res = binary.BigEndian.AppendUint32(res, uint32(len(t.Data)))
+		res = append(res, t.Data...)
+	}
+
+	size := len(res) - start
+	footer := len(res)
+	res = binary.BigEndian.AppendUint32(res, uint32(size))
+	res = binary.BigEndian.AppendUint32(res, crc32.ChecksumIEEE(res[start:start+size]))
+	res = binary.BigEndian.AppendUint32(res, crc32.ChecksumIEEE(res[footer:]))
+	res = append(res, footerMagic...)
+	res = append(res, res[footer:]...)
+
+	return res, nil
+}
+
+// Split returns content and its trailers, data without trailers is content
+func Split(data []byte) ([]byte, []Trailer, error){
+	contentSize, trailers, err := parse(data, int64(len(data)), true)
+	if err != nil{
+		return nil, nil, err
+	}
+
+	return data[:contentSize], trailers, nil
+}
+
+// SplitDamaged is Split which ignores the checksum of trailers and a damaged footer copy,
+// so trailers checking their own data, like recovery records, can be read from damaged files
+func SplitDamaged(data []byte) ([]byte, []Trailer, error){
+	contentSize, trailers, err := parse(data, int64(len(data)), false)
+	if err != nil{
+		return nil, nil, err
+	}
+
+	return data[:contentSize], trailers, nil
+}
+
+// Read reads trailers of file of the given size and returns the content size
+func Read(r io.ReaderAt, size int64) (int64, []Trailer, error){
+	if size < int64(footersSize){
+		return size, nil, nil
+	}
+
+	footers := make([]byte, footersSize)
+	if _, err := r.ReadAt(footers, size-int64(footersSize)); err != nil{
+		return 0, nil, err
+	}
+	footer, _ := findFooter(footers)
+	if footer == nil{
+		return size, nil, nil
+	}
+
+	trailersSize := int64(binary.BigEndian.Uint32(footer))
+	if trailersSize > size-int64(footersSize){
+		return 0, nil, fmt.Errorf("%w: trailers size %d", ErrInvalidTrailers, trailersSize)
+	}
+
+	start := size - int64(footersSize) - trailersSize
+	section := make([]byte, trailersSize + int64(footersSize))
+	if _, err := r.ReadAt(section, start); err != nil{
+		return 0, nil, err
+	}
+
+	// parse expects the content before trailers, its size doesn't matter
+	contentSize, trailers, err := parse(section, int64(len(section)), true)
+	if err != nil{
+		return 0, nil, err
+	}
+
+	return start + contentSize, trailers, nil
+}
+
+// isFooter reports whether footer ends trailers: it has the magic and its checksum matches
+func isFooter(footer []byte) bool{
+	return string(footer[12:]) == footerMagic && crc32.ChecksumIEEE(footer[:8]) == binary.BigEndian.Uint32(footer[8:])
+}
+
+// findFooter returns an intact copy of the footer of footers and whether the last copy is damaged,
+// the footer is nil if both copies are damaged or footers aren't footers at all
+func findFooter(footers []byte) ([]byte, bool){
+	first, last := footers[:footerSize], footers[footerSize:]
+
+	switch{
+		case isFooter(last):
+			return last, false
+		case isFooter(first):
+			return first, true
+	}
+
+	return nil, false
+}
+
+func parse(data []byte, size int64, verify bool) (int64, []Trailer, error){
+	if size < int64(footersSize){
+		return size, nil, nil
+	}
+
+	end := size - int64(footersSize)
+	footer, damaged := findFooter(data[end:size])
+	switch{
+		case footer == nil:
+			return size, nil, nil
+		case verify && damaged:
+			return 0, nil, fmt.Errorf("%w: %w", ErrInvalidTrailers, ErrDamagedFooter)
+	}
+
+	trailersSize := int64(binary.BigEndian.Uint32(footer))
+	if trailersSize > end{
+		return 0, nil, fmt.Errorf("%w: trailers size %d", ErrInvalidTrailers, trailersSize)
+	}
+
+	start := end - trailersSize
+	section := data[start:end]
+
+	if verify && crc32.ChecksumIEEE(section) != binary.BigEndian.Uint32(footer[4:]){
+		return 0, nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidTrailers)
+	}
+
+	var trailers []Trailer
+	for len(section) > 0{
+		if len(section) < entryHeaderSize{
+			return 0, nil, fmt.Errorf("%w: truncated trailer", ErrInvalidTrailers)
+		}
+
+		t, dataSize := section[0], binary.BigEndian.Uint32(section[1:])
+		section = section[entryHeaderSize:]
+
+		if uint64(dataSize) > uint64(len(section)){
+			return 0, nil, fmt.Errorf("%w: trailer size %d", ErrInvalidTrailers, dataSize)
+		}
+
+		trailers = append(trailers, Trailer{Type: t, Data: section[:dataSize]})
+		section = section[dataSize:]
+	}
+
+	return start, trailers, nil
+}
@@ -0,0 +1,90 @@
+package trailer
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"reflect"
+)
+
+func TestAppend(t* testing.T){
+	content := []byte("packed content")
+
+	data, err := Append(content, Trailer{Type: Signature, Data: []byte("first")})
+	if err != nil{
+		t.Fatalf("Append() error = %v", err)
+	}
+	data, err = Append(data, Trailer{Type: Recovery, Data: []byte("second")})
+	if err != nil{
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	want := []Trailer{{Type: Signature, Data: []byte("first")}, {Type: Recovery, Data: []byte("second")}}
+
+	gotContent, got, err := Split(data)
+	if err != nil || !bytes.Equal(gotContent, content) || !reflect.DeepEqual(got, want){
+		t.Errorf("Split() = #%q %v %v#, want #%q %v#", gotContent, got, err, content, want)
+	}
+
+	size, got, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil || size != int64(len(content)) || !reflect.DeepEqual(got, want){
+		t.Errorf("Read() = #%d %v %v#, want #%d %v#", size, got, err, len(content), want)
+	}
+}
+
+func TestSplit(t* testing.T){
+	data, _ := Append([]byte("content"), Trailer{Type: Signature, Data: []byte("signature")})
+
+	damaged := append([]byte(nil), data...)
+	damaged[len("content")+6] ^= 1
+
+	damagedFooter := append([]byte(nil), data...)
+	damagedFooter[len(data)-1] ^= 1
+
+	damagedCopy := append([]byte(nil), data...)
+	damagedCopy[len(data)-footerSize-1] ^= 1
+
+	tests := []struct{
+		name string
+		data []byte
+		wantContent string
+		wantErr error
+	}{
+		{name: "no trailers", data: []byte("content"), wantContent: "content"},
+		// the size would be 1651471732
+		{name: "content ending with the magic", data: []byte("a goat and another GOAT"), wantContent: "a goat and another GOAT"},
+		{name: "content ending with an old footer", data: []byte("content\x00\x00\x00\x00\x00\x00\x00\x00GOAT"), wantContent: "content\x00\x00\x00\x00\x00\x00\x00\x00GOAT"},
+		{name: "empty", data: nil, wantContent: ""},
+		{name: "damaged trailer", data: damaged, wantErr: ErrInvalidTrailers},
+		{name: "damaged footer", data: damagedFooter, wantErr: ErrDamagedFooter},
+		{name: "damaged footer copy", data: damagedCopy, wantContent: "content"},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			content, _, err := Split(tt.data)
+			if !errors.Is(err, tt.wantErr) || string(content) != tt.wantContent{
+				t.Errorf("Split() = #%q %v#, want #%q %v#", content, err, tt.wantContent, tt.wantErr)
+			}
+
+			size, _, err := Read(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if !errors.Is(err, tt.wantErr) || (err == nil && size != int64(len(tt.wantContent))){
+				t.Errorf("Read() = #%d %v#, want #%d %v#", size, err, len(tt.wantContent), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitDamaged(t* testing.T){
+	data, _ := Append([]byte("content"), Trailer{Type: Recovery, Data: []byte("parity")})
+	// type | size | the first byte of data
+	data[len("content")+5] ^= 1
+	// the last footer copy
+	data[len(data)-1] ^= 1
+
+	content, got, err := SplitDamaged(data)
+	want := []Trailer{{Type: Recovery, Data: []byte("qarity")}}
+
+	if err != nil || string(content) != "content" || !reflect.DeepEqual(got, want){
+		t.Errorf("SplitDamaged() = #%q %v %v#, want #%q %v#", content, got, err, "content", want)
+	}
+}
@@ -0,0 +1,221 @@
+package volume
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// volumeMagic starts every volume
+const volumeMagic = "GOAV"
+
+// setIDSize is the size of ID shared by volumes of one file
+const setIDSize = 16
+
+// HeaderSize is magic | set ID | volume number | volumes count | header checksum
+const HeaderSize = len(volumeMagic) + setIDSize + 4 + 4 + 4
+
+var ErrVolumeSize = errors.New("volume size must be larger than the volume header")
+var ErrInvalidVolume = errors.New("invalid volume")
+var ErrMissingVolume = errors.New("missing volume")
+var ErrVolumeOrder = errors.New("volumes are out of order")
+var ErrVolumeSet = errors.New("volume belongs to another file")
+
+type header struct{
+	setID [setIDSize]byte
+	number int
+	count int
+}
+
+// Split splits data into volumes of volumeSize bytes at most including their headers,
+// volumes are numbered from 1. The set ID is derived from data,
+// so volumes of different files aren't mixed up.
+func Split(data []byte, volumeSize int64) ([][]byte, error){
+	if volumeSize <= int64(HeaderSize){
+		return nil, fmt.Errorf("%w: %d bytes", ErrVolumeSize, volumeSize)
+	}
+	partSize := int(min(volumeSize - int64(HeaderSize), int64(len(data))))
+
+	count := 1
+	if len(data) > 0{
+		count = (len(data) + partSize - 1) / partSize
+	}
+
+	sum := sha256.Sum256(data)
+	h := header{count: count}
+	copy(h.setID[:], sum[:])
+
+	volumes := make([][]byte, 0, count)
+	for i := 0; i < count; i++{
+		h.number = i + 1
+
+		part := data[min(i*partSize, len(data)):min((i+1)*partSize, len(data))]
+		volumes = append(volumes, append(h.encode(), part...))
+	}
+
+	return volumes, nil
+}
+
+// Join returns data of volumes given in order
+func Join(volumes [][]byte) ([]byte, error){
+	parts := make([]io.ReaderAt, len(volumes))
+	sizes := make([]int64, len(volumes))
+	for i, v := range volumes{
+		parts[i], sizes[i] = bytes.NewReader(v), int64(len(v))
+	}
+
+	r, err := NewReader(parts, sizes)
+	if err != nil{
+		return nil, err
+	}
+
+	return io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+}
+
+// IsVolume reports whether data starts with a volume header
+func IsVolume(data []byte) bool{
+	return bytes.HasPrefix(data, []byte(volumeMagic))
+}
+
+// Count returns the number of volumes from the header of any of them
+func Count(head []byte) (int, error){
+	h, err := decodeHeader(head)
+
+	return h.count, err
+}
+
+// Name returns the name of volume number of file path: file.vlc.001
+func Name(path string, number int) string{
+	return fmt.Sprintf("%s.%03d", path, number)
+}
+
+
+// Reader reads data of volumes as one file
+type Reader struct{
+	parts []io.ReaderAt
+	// offsets of volumes in data, the last one is the data size
+	offsets []int64
+}
+
+// NewReader checks headers of volumes of the given sizes,
+// they must be all volumes of one file in order
+func NewReader(parts []io.ReaderAt, sizes []int64) (*Reader, error){
+	if len(parts) == 0{
+		return nil, fmt.Errorf("%w: volume 1", ErrMissingVolume)
+	}
+
+	r := &Reader{parts: parts, offsets: []int64{0}}
+	var first header
+
+	for i, part := range parts{
+		if sizes[i] < int64(HeaderSize){
+			return nil, fmt.Errorf("%w: volume %d is too short", ErrInvalidVolume, i+1)
+		}
+
+		head := make([]byte, HeaderSize)
+		if _, err := part.ReadAt(head, 0); err != nil{
+			return nil, err
+		}
+
+		h, err := decodeHeader(head)
+		if err != nil{
+			return nil, fmt.Errorf("volume %d: %w", i+1, err)
+		}
+
+		if i == 0{
+			first = h
+		}
+		switch{
+			case h.setID != first.setID:
+				return nil, fmt.Errorf("%w: volume %d", ErrVolumeSet, i+1)
+			case h.number != i+1:
+				return nil, fmt.Errorf("%w: volume %d is given as %d", ErrVolumeOrder, h.number, i+1)
+		}
+
+		r.offsets = append(r.offsets, r.offsets[i] + sizes[i] - int64(HeaderSize))
+	}
+
+	if len(parts) < first.count{
+		return nil, fmt.Errorf("%w: volume %d of %d", ErrMissingVolume, len(parts)+1, first.count)
+	}
+	if len(parts) > first.count{
+		return nil, fmt.Errorf("%w: %d volumes, want %d", ErrInvalidVolume, len(parts), first.count)
+	}
+
+	return r, nil
+}
+
+// Size returns size of data of all volumes
+func (r *Reader) Size() int64{
+	return r.offsets[len(r.offsets)-1]
+}
+
+func (r *Reader) ReadAt(p []byte, off int64) (int, error){
+	if off < 0{
+		return 0, fmt.Errorf("%w: negative offset", ErrInvalidVolume)
+	}
+
+	n := 0
+	for len(p) > 0{
+		if off >= r.Size(){
+			return n, io.EOF
+		}
+
+		// the volume holding off
+		i := sort.Search(len(r.parts), func(i int) bool {
+			return r.offsets[i+1] > off
+		})
+
+		chunk := p[:min(int64(len(p)), r.offsets[i+1]-off)]
+		read, err := r.parts[i].ReadAt(chunk, off - r.offsets[i] + int64(HeaderSize))
+		n += read
+		if err != nil && !(err == io.EOF && read == len(chunk)){
+			return n, err
+		}
+
+		p, off = p[read:], off + int64(read)
+	}
+
+	return n, nil
+}
+
+
+func (h header) encode() []byte{
+	res := make([]byte, 0, HeaderSize)
+
+	res = append(res, volumeMagic...)
+	res = append(res, h.setID[:]...)
+	res = binary.BigEndian.AppendUint32(res, uint32(h.number))
+	res = binary.BigEndian.AppendUint32(res, uint32(h.count))
+
+	return binary.BigEndian.AppendUint32(res, crc32.ChecksumIEEE(res))
+}
+
+func decodeHeader(data []byte) (header, error){
+	var h header
+
+	if len(data) < HeaderSize || !IsVolume(data){
+		return h, fmt.Errorf("%w: volume header not found", ErrInvalidVolume)
+	}
+
+	data = data[:HeaderSize]
+	if crc32.ChecksumIEEE(data[:HeaderSize-4]) != binary.BigEndian.Uint32(data[HeaderSize-4:]){
+		return h, fmt.Errorf("%w: header checksum mismatch", ErrInvalidVolume)
+	}
+
+	data = data[len(volumeMagic):]
+	copy(h.setID[:], data)
+	h.number = int(binary.BigEndian.Uint32(data[setIDSize:]))
+	h.count = int(binary.BigEndian.Uint32(data[setIDSize+4:]))
+
+	if h.count < 1 || h.number < 1 || h.number > h.count{
+		return h, fmt.Errorf("%w: volume %d of %d", ErrInvalidVolume, h.number, h.count)
+	}
+
+	return h, nil
+}
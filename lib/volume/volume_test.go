@@ -0,0 +1,140 @@
+package volume
+
+import (
+	"testing"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+)
+
+func TestSplitJoin(t* testing.T){
+	tests := []struct{
+		name string
+		size int
+		volumeSize int64
+		wantCount int
+	}{
+		{name: "one volume", size: 100, volumeSize: 1000, wantCount: 1},
+		{name: "exact volumes", size: 300, volumeSize: int64(HeaderSize) + 100, wantCount: 3},
+		{name: "last volume is shorter", size: 301, volumeSize: int64(HeaderSize) + 100, wantCount: 4},
+		{name: "empty", size: 0, volumeSize: 1000, wantCount: 1},
+		{name: "one byte volumes", size: 10, volumeSize: int64(HeaderSize) + 1, wantCount: 10},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			data := make([]byte, tt.size)
+			rand.New(rand.NewSource(int64(tt.size))).Read(data)
+
+			volumes, err := Split(data, tt.volumeSize)
+			if err != nil{
+				t.Fatalf("Split() error = %v", err)
+			}
+			if len(volumes) != tt.wantCount{
+				t.Fatalf("Split() = %d volumes, want %d", len(volumes), tt.wantCount)
+			}
+			for i, v := range volumes{
+				if int64(len(v)) > tt.volumeSize{
+					t.Errorf("volume %d size = %d, want at most %d", i+1, len(v), tt.volumeSize)
+				}
+				if !IsVolume(v){
+					t.Errorf("IsVolume(volume %d) = false", i+1)
+				}
+				if count, err := Count(v); err != nil || count != tt.wantCount{
+					t.Errorf("Count(volume %d) = #%d %v#, want #%d#", i+1, count, err, tt.wantCount)
+				}
+			}
+
+			got, err := Join(volumes)
+			if err != nil{
+				t.Fatalf("Join() error = %v", err)
+			}
+			if !bytes.Equal(got, data){
+				t.Errorf("Join() differs from the original")
+			}
+		})
+	}
+}
+
+func TestSplit_VolumeSize(t* testing.T){
+	if _, err := Split([]byte("data"), int64(HeaderSize)); !errors.Is(err, ErrVolumeSize){
+		t.Errorf("Split() error = #%v#, want #%v#", err, ErrVolumeSize)
+	}
+}
+
+func TestJoin_Errors(t* testing.T){
+	volumes, _ := Split(bytes.Repeat([]byte("data"), 100), int64(HeaderSize) + 100)
+	other, _ := Split(bytes.Repeat([]byte("other"), 100), int64(HeaderSize) + 100)
+
+	damaged := append([]byte(nil), volumes[1]...)
+	damaged[len(volumeMagic)] ^= 1
+
+	tests := []struct{
+		name string
+		volumes [][]byte
+		wantErr error
+	}{
+		{name: "no volumes", volumes: nil, wantErr: ErrMissingVolume},
+		{name: "missing last", volumes: volumes[:3], wantErr: ErrMissingVolume},
+		{name: "missing middle", volumes: [][]byte{volumes[0], volumes[2], volumes[3]}, wantErr: ErrVolumeOrder},
+		{name: "reordered", volumes: [][]byte{volumes[0], volumes[2], volumes[1], volumes[3]}, wantErr: ErrVolumeOrder},
+		{name: "another file", volumes: [][]byte{volumes[0], other[1], volumes[2], volumes[3]}, wantErr: ErrVolumeSet},
+		{name: "damaged header", volumes: [][]byte{volumes[0], damaged, volumes[2], volumes[3]}, wantErr: ErrInvalidVolume},
+		{name: "not a volume", volumes: [][]byte{[]byte("plain file content is not a volume")}, wantErr: ErrInvalidVolume},
+		{name: "too short", volumes: [][]byte{volumes[0][:HeaderSize-1]}, wantErr: ErrInvalidVolume},
+		{name: "extra volume", volumes: append(append([][]byte(nil), volumes...), volumes[3]), wantErr: ErrVolumeOrder},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			if _, err := Join(tt.volumes); !errors.Is(err, tt.wantErr){
+				t.Errorf("Join() error = #%v#, want #%v#", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReader_ReadAt(t* testing.T){
+	data := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	volumes, _ := Split(data, int64(HeaderSize) + 64)
+	parts := make([]io.ReaderAt, len(volumes))
+	sizes := make([]int64, len(volumes))
+	for i, v := range volumes{
+		parts[i], sizes[i] = bytes.NewReader(v), int64(len(v))
+	}
+
+	r, err := NewReader(parts, sizes)
+	if err != nil{
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if r.Size() != int64(len(data)){
+		t.Fatalf("Size() = %d, want %d", r.Size(), len(data))
+	}
+
+	tests := []struct{
+		name string
+		off int64
+		length int
+		wantN int
+		wantErr error
+	}{
+		{name: "inside volume", off: 10, length: 20, wantN: 20},
+		{name: "across volumes", off: 60, length: 200, wantN: 200},
+		{name: "volume boundary", off: 64, length: 64, wantN: 64},
+		{name: "tail", off: 990, length: 20, wantN: 10, wantErr: io.EOF},
+		{name: "past end", off: 1000, length: 1, wantN: 0, wantErr: io.EOF},
+	}
+	for _, tt := range tests{
+		t.Run(tt.name, func(t* testing.T){
+			p := make([]byte, tt.length)
+			n, err := r.ReadAt(p, tt.off)
+			if n != tt.wantN || !errors.Is(err, tt.wantErr){
+				t.Fatalf("ReadAt() = #%d %v#, want #%d %v#", n, err, tt.wantN, tt.wantErr)
+			}
+			if !bytes.Equal(p[:n], data[tt.off:tt.off+int64(n)]){
+				t.Errorf("ReadAt() data differs")
+			}
+		})
+	}
+}